@@ -0,0 +1,183 @@
+package safenet
+
+import (
+	"fmt"
+	"io"
+)
+
+// A vault joining the network may be rejected by the section it would join.
+// Rejected vaults wait in the join queue and are offered to the network again
+// at the start of later steps, at most joinRetriesPerStep per step from the
+// front of the queue. Vaults rejected again go to the back, so every queued
+// vault is offered in turn. A step is one call to AddVault or RemoveVault
+// from outside the network.
+
+const joinRetriesPerStep = 100
+
+// Decides whether a vault may join a section. Relocated vaults are always
+// admitted since the network chose to move them.
+type AdmissionPolicy interface {
+	Admit(s *Section, v *Vault) bool
+}
+
+// Admits every vault. This is the default when Network.Admission is nil.
+type AlwaysAdmit struct{}
+
+func (a AlwaysAdmit) Admit(s *Section, v *Vault) bool {
+	return true
+}
+
+// The rule from ageing_sim, a section that is complete (all elders are
+// adults) admits only one vault aged 1 at a time.
+// see https://github.com/fizyk20/ageing_sim/blob/53829350daa372731c9b8080488b2a75c72f60bb/src/network/section.rs#L198
+type AgeingSimAdmission struct{}
+
+func (a AgeingSimAdmission) Admit(s *Section, v *Vault) bool {
+	return !(v.Age == 1 && s.hasVaultAgedOne() && s.isComplete())
+}
+
+// Admits at most Joins vaults to each section in any Steps consecutive steps.
+type RateLimitAdmission struct {
+	Joins int
+	Steps int
+	// joins admitted within the window, oldest first. Sections count the
+	// joins matching their prefix, so the window carries over when sections
+	// split or merge.
+	recent []admittedJoin
+}
+
+type admittedJoin struct {
+	step int
+	name XorName
+}
+
+func NewRateLimitAdmission(joins, steps int) *RateLimitAdmission {
+	return &RateLimitAdmission{
+		Joins:  joins,
+		Steps:  steps,
+		recent: []admittedJoin{},
+	}
+}
+
+func (r *RateLimitAdmission) Admit(s *Section, v *Vault) bool {
+	now := s.network.steps
+	// forget joins older than the window
+	expired := 0
+	for expired < len(r.recent) && r.recent[expired].step <= now-r.Steps {
+		expired = expired + 1
+	}
+	r.recent = r.recent[expired:]
+	joins := 0
+	for _, j := range r.recent {
+		if s.Prefix.Matches(j.name) {
+			joins = joins + 1
+		}
+	}
+	if joins >= r.Joins {
+		return false
+	}
+	r.recent = append(r.recent, admittedJoin{now, v.Name})
+	return true
+}
+
+// Names of the admission policies for NewAdmissionPolicy
+const (
+	AlwaysAdmitName        = "always"
+	AgeingSimAdmissionName = "ageing-sim"
+	RateLimitAdmissionName = "rate-limit"
+)
+
+// Creates a policy by name. joins and steps are only used by the rate limit.
+func NewAdmissionPolicy(name string, joins, steps int) (AdmissionPolicy, error) {
+	switch name {
+	case "", AlwaysAdmitName:
+		return AlwaysAdmit{}, nil
+	case AgeingSimAdmissionName:
+		return AgeingSimAdmission{}, nil
+	case RateLimitAdmissionName:
+		if joins < 1 || steps < 1 {
+			return nil, fmt.Errorf("rate limit needs at least 1 join per step, got %d joins per %d steps", joins, steps)
+		}
+		return NewRateLimitAdmission(joins, steps), nil
+	}
+	return nil, fmt.Errorf("unknown admission policy %s, use %s, %s or %s", name, AlwaysAdmitName, AgeingSimAdmissionName, RateLimitAdmissionName)
+}
+
+type queuedJoin struct {
+	vault *Vault
+	// the step the vault was first rejected
+	since int
+}
+
+// Starts a step if called from outside the network, retrying the join queue.
+func (n *Network) beginStep() {
+	if len(n.eventCauses) > 0 {
+		return
+	}
+	n.steps = n.steps + 1
+	if len(n.joinQueue) == 0 {
+		return
+	}
+	queue := n.joinQueue
+	retries := len(queue)
+	if retries > joinRetriesPerStep {
+		retries = joinRetriesPerStep
+	}
+	n.joinQueue = queue[retries:]
+	for _, q := range queue[:retries] {
+		if !n.admit(q.vault) {
+			n.joinQueue = append(n.joinQueue, q)
+			continue
+		}
+		n.JoinWaits = append(n.JoinWaits, n.steps-q.since)
+		n.joinVault(q.vault)
+	}
+}
+
+func (n *Network) admit(v *Vault) bool {
+	if n.Admission == nil {
+		return true
+	}
+	s := n.sections.longestMatch(v.Name)
+	if s == nil {
+		return true
+	}
+	return n.Admission.Admit(s, v)
+}
+
+func (n *Network) enqueue(v *Vault) {
+	n.TotalRejections = n.TotalRejections + 1
+	n.joinQueue = append(n.joinQueue, queuedJoin{v, n.steps})
+	if len(n.joinQueue) > n.MaxJoinQueueLength {
+		n.MaxJoinQueueLength = len(n.joinQueue)
+	}
+}
+
+// Vaults rejected by the admission policy and waiting to join
+func (n *Network) JoinQueueLength() int {
+	return len(n.joinQueue)
+}
+
+// Adds the join queue metrics of the network to the result totals
+func (r *SeedResult) AddAdmissionStats(n *Network) {
+	r.Totals["rejected joins"] = float64(n.TotalRejections)
+	r.Totals["max join queue length"] = float64(n.MaxJoinQueueLength)
+	r.Totals["final join queue length"] = float64(n.JoinQueueLength())
+	waits := r.Histogram("join wait")
+	totalWait := 0
+	for _, w := range n.JoinWaits {
+		waits.Add(w)
+		totalWait = totalWait + w
+	}
+	if len(n.JoinWaits) > 0 {
+		r.Totals["mean join wait"] = float64(totalWait) / float64(len(n.JoinWaits))
+	}
+}
+
+// Prints the join queue metrics added by AddAdmissionStats
+func PrintAdmissionStats(w io.Writer, results []*SeedResult) {
+	PrintTotalStats(w, results, "rejected joins")
+	PrintTotalStats(w, results, "max join queue length")
+	PrintTotalStats(w, results, "final join queue length")
+	PrintTotalStats(w, results, "mean join wait")
+}
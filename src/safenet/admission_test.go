@@ -0,0 +1,159 @@
+package safenet
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRateLimitQueuesJoins(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	n.Admission = NewRateLimitAdmission(1, 5)
+	// the first vault creates the section so is not limited
+	n.AddVault(NewVault(n))
+	if n.AddVault(NewVault(n)) {
+		t.Error("First vault in the window was rejected")
+	}
+	if !n.AddVault(NewVault(n)) {
+		t.Error("Second vault in the same window was admitted")
+	}
+	if n.JoinQueueLength() != 1 || n.TotalRejections != 1 || n.TotalVaults() != 2 {
+		t.Errorf("Queue has %d vaults after %d rejections", n.JoinQueueLength(), n.TotalRejections)
+	}
+	// the queued vault joins before the newer vault once the window passes
+	for i := 0; i < 4; i++ {
+		n.AddVault(NewVault(n))
+	}
+	if n.TotalVaults() != 3 || len(n.JoinWaits) != 1 || n.JoinWaits[0] != 4 {
+		t.Errorf("%d vaults after waits %v", n.TotalVaults(), n.JoinWaits)
+	}
+	if len(n.CheckInvariants()) > 0 {
+		t.Error(n.CheckInvariants())
+	}
+}
+
+func TestRateLimitSurvivesSplit(t *testing.T) {
+	n := NewNetwork(DefaultParams())
+	r := NewRateLimitAdmission(1, 10)
+	parent := &Section{Prefix: NewBlankPrefix(), network: n}
+	v := NewVault(n)
+	if !r.Admit(parent, v) {
+		t.Fatal("First vault was rejected")
+	}
+	// after a split the child holding the vault is still limited
+	child := &Section{Prefix: parent.Prefix.extend(v.Name.GetBit(0)), network: n}
+	sibling := &Section{Prefix: child.Prefix.sibling(), network: n}
+	w := NewVault(n)
+	w.renameWithPrefix(n, child.Prefix)
+	if r.Admit(child, w) {
+		t.Error("Child section admitted a second vault in the window")
+	}
+	w.renameWithPrefix(n, sibling.Prefix)
+	if !r.Admit(sibling, w) {
+		t.Error("Sibling section rejected its first vault")
+	}
+	// a merge counts the joins of both children
+	if r.Admit(parent, NewVault(n)) {
+		t.Error("Merged section admitted a vault in the window")
+	}
+	// joins older than the window are forgotten
+	n.steps = n.steps + 10
+	if !r.Admit(child, NewVault(n)) || len(r.recent) != 1 {
+		t.Errorf("Expected only the new join in the window, got %d", len(r.recent))
+	}
+}
+
+func TestAgeingSimNetworkGrows(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	n.Admission = AgeingSimAdmission{}
+	n.SimulateChurn(1000, 3000, nil)
+	if n.TotalVaults() != 1000 || n.TotalSections() < 8 {
+		t.Errorf("Network grew to %d vaults in %d sections", n.TotalVaults(), n.TotalSections())
+	}
+	// most rejected vaults have joined since
+	if n.TotalRejections == 0 || n.JoinQueueLength() > n.TotalRejections/10 || len(n.JoinWaits) == 0 {
+		t.Errorf("%d of %d rejected vaults are still queued", n.JoinQueueLength(), n.TotalRejections)
+	}
+	if len(n.CheckInvariants()) > 0 {
+		t.Error(n.CheckInvariants())
+	}
+}
+
+// rejects every vault and counts how often it was asked
+type rejectAll struct {
+	calls int
+}
+
+func (r *rejectAll) Admit(s *Section, v *Vault) bool {
+	r.calls = r.calls + 1
+	return false
+}
+
+func TestJoinRetriesAreLimited(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	n.AddVault(NewVault(n))
+	r := &rejectAll{}
+	n.Admission = r
+	for i := 0; i < joinRetriesPerStep+50; i++ {
+		n.joinVault(NewVault(n))
+		n.enqueue(NewVault(n))
+	}
+	first := n.joinQueue[0].vault
+	r.calls = 0
+	n.AddVault(NewVault(n))
+	// the retries and the new vault
+	if r.calls != joinRetriesPerStep+1 {
+		t.Errorf("Admission was asked %d times", r.calls)
+	}
+	// vaults offered again go to the back of the queue
+	if n.joinQueue[0].vault == first || n.joinQueue[len(n.joinQueue)-joinRetriesPerStep-1].vault != first {
+		t.Error("Offered vaults did not move to the back of the queue")
+	}
+}
+
+func TestAgeingSimAdmission(t *testing.T) {
+	n := NewNetwork(DefaultParams())
+	s := &Section{network: n}
+	for i := 0; i < n.Params.GroupSize; i++ {
+		v := NewVault(n)
+		v.Age = n.Params.AdultAge + 1
+		s.appendVault(v)
+	}
+	infant := NewVault(n)
+	a := AgeingSimAdmission{}
+	if !a.Admit(s, infant) {
+		t.Error("First infant was rejected")
+	}
+	s.appendVault(infant)
+	if a.Admit(s, NewVault(n)) {
+		t.Error("Second infant was admitted to a complete section")
+	}
+	adult := NewVault(n)
+	adult.Age = 2
+	if !a.Admit(s, adult) {
+		t.Error("Older vault was rejected")
+	}
+}
+
+func TestSnapshotKeepsJoinQueue(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	n.Admission = NewRateLimitAdmission(1, 100)
+	for i := 0; i < 10; i++ {
+		n.AddVault(NewVault(n))
+	}
+	buf := bytes.Buffer{}
+	err := n.WriteSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.JoinQueueLength() != 8 || restored.TotalRejections != 8 {
+		t.Errorf("Restored queue has %d vaults", restored.JoinQueueLength())
+	}
+	restored.RemoveVault(restored.GetRandomVault())
+	if restored.TotalVaults() != 9 || restored.JoinQueueLength() != 0 {
+		t.Errorf("Restored queue did not join, %d vaults", restored.TotalVaults())
+	}
+}
@@ -0,0 +1,70 @@
+package safenet
+
+import (
+	"sort"
+)
+
+// The vaults of a section ordered oldest first, with ties in age broken as in
+// oldestFirst, so elders, adults and relocation candidates are found without
+// sorting or scanning every vault. Vaults must not change age or name while
+// in an index, which holds since vaults only age and are renamed when they
+// are relocated, after leaving their section.
+type ageIndex []*Vault
+
+// Returns the position of the first vault which is not older than v, which
+// is where v is or would be inserted.
+func (a ageIndex) search(v *Vault) int {
+	return sort.Search(len(a), func(i int) bool {
+		return !isOlder(a[i], v)
+	})
+}
+
+func (a *ageIndex) insert(v *Vault) {
+	i := a.search(v)
+	*a = append(*a, nil)
+	copy((*a)[i+1:], (*a)[i:])
+	(*a)[i] = v
+}
+
+// Returns false if v is not in the index.
+func (a *ageIndex) remove(v *Vault) bool {
+	i := a.search(v)
+	if i == len(*a) || (*a)[i] != v {
+		return false
+	}
+	*a = append((*a)[:i], (*a)[i+1:]...)
+	return true
+}
+
+// Returns the vaults aged at most age, oldest first.
+func (a ageIndex) agedAtMost(age int) ageIndex {
+	i := sort.Search(len(a), func(i int) bool {
+		return a[i].Age <= age
+	})
+	return a[i:]
+}
+
+// Returns the vaults with the same age as the first vault.
+func (a ageIndex) sameAge() ageIndex {
+	if len(a) == 0 {
+		return a
+	}
+	return a[:len(a)-len(a.agedAtMost(a[0].Age-1))]
+}
+
+// Adds a vault to the section, keeping Vaults in joining order for the
+// random choices made from it.
+func (s *Section) appendVault(v *Vault) {
+	s.Vaults = append(s.Vaults, v)
+	s.byAge.insert(v)
+}
+
+func (s *Section) deleteVault(v *Vault) {
+	for i, vault := range s.Vaults {
+		if vault == v {
+			s.Vaults = append(s.Vaults[:i], s.Vaults[i+1:]...)
+			break
+		}
+	}
+	s.byAge.remove(v)
+}
@@ -0,0 +1,40 @@
+package safenet
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestAgeIndexOrder(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	vaults := []*Vault{}
+	var a ageIndex
+	for i := 0; i < 50; i++ {
+		v := NewVault(n)
+		v.Age = i % 7
+		vaults = append(vaults, v)
+		a.insert(v)
+	}
+	for _, v := range vaults[:10] {
+		if !a.remove(v) {
+			t.Error("Vault not found in index")
+		}
+	}
+	if a.remove(vaults[0]) {
+		t.Error("Removed a vault which is not in the index")
+	}
+	expected := append([]*Vault{}, vaults[10:]...)
+	sort.Sort(oldestFirst(expected))
+	if len(a) != len(expected) {
+		t.Fatalf("Expected %d vaults, got %d", len(expected), len(a))
+	}
+	for i := range expected {
+		if a[i] != expected[i] {
+			t.Fatalf("Vault %d is out of order", i)
+		}
+	}
+	// ages are 6 down to 0 with 40 vaults left
+	if len(a.agedAtMost(2)) != 16 || len(a.sameAge()) != 6 || a.sameAge()[0].Age != 6 {
+		t.Errorf("Got %d vaults aged at most 2 and %d oldest", len(a.agedAtMost(2)), len(a.sameAge()))
+	}
+}
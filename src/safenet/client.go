@@ -1,5 +1,9 @@
 package safenet
 
+import (
+	"encoding/binary"
+)
+
 // clients do the following activities:
 // upload data
 // fetch data
@@ -25,22 +29,33 @@ type Operator interface {
 	NewVaultsToStart() []*Vault
 	ExistingVaultsToStop() []*Vault
 	ConvertCoinsToPutBalance(int, Uploader, *Network)
+	// once the client is added to a network its coins are changed by the
+	// network, calling this directly makes Network.TotalSafecoins drift
 	AllocateSafecoins(int32)
 	TotalSafecoins() int32
 	AllocatePuts(float64)
 	TotalPutBalance() float64
 }
 
-func NewRandomClient() Client {
+func NewRandomClient(n *Network) Client {
 	var clientTypes float64 = 2 // update this if adding new client type
-	classifier := prng.Float64()
+	classifier := n.rng.clients.Float64()
 	if classifier < 1/clientTypes {
-		return NewConsistentClient()
+		return NewConsistentClient(n)
 		//} else if classifier < 2/clientTypes {
-		//	return NewTemplateClient()
+		//	return NewTemplateClient(n)
 		//} else if classifier < 3/clientTypes {
-		//	return NewYourInterestingClient()
+		//	return NewYourInterestingClient(n)
 	} else {
-		return NewInconsistentClient()
+		return NewInconsistentClient(n)
 	}
 }
+
+// Creates a random 8 byte id. The bytes come from a single Uint64 rather than
+// prng.Read, since Read buffers unused bytes which can't be saved in a
+// snapshot.
+func newClientId(n *Network) string {
+	idBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(idBytes, n.rng.clients.Uint64())
+	return string(idBytes)
+}
@@ -1,51 +1,132 @@
 package safenet
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
 )
 
-func LoadConfigInt(filename, paramName string, defaultValue int) int {
-	content, err := ioutil.ReadFile(filename)
+// Every config is layered, with values from the command line taking priority
+// over the config file for the simulation, which takes priority over
+// config_all.json, which takes priority over the defaults.
+
+const configAllFilename = "config_all.json"
+
+// Fills config, a pointer to a struct holding the default values, from
+// config_all.json and then filename. Either file may be missing.
+// Values of the wrong type are errors, as are keys in filename which do not
+// match a field. config_all.json is shared by every command so its keys only
+// need to match the fields of some commands.
+func LoadConfig(filename string, config interface{}) error {
+	for _, f := range []string{configAllFilename, filename} {
+		content, err := ioutil.ReadFile(f)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		d := json.NewDecoder(bytes.NewReader(content))
+		if f != configAllFilename {
+			d.DisallowUnknownFields()
+		}
+		err = d.Decode(config)
+		if err != nil {
+			return fmt.Errorf("error reading config %s: %w", f, err)
+		}
+	}
+	return nil
+}
+
+// Parses args into fs and fills config from the config files and the flags.
+// A -config flag chooses the config file, defaulting to defaultFilename, and
+// every int, float, string and bool field of config gets a flag named by its
+// json key with dashes for underscores. Fields of embedded structs are
+// flags of their own, and fields of nested structs are prefixed by the name
+// of the nested struct, eg -params-group-size. The usage tag of a field is
+// used as the flag usage.
+func ParseConfig(fs *flag.FlagSet, args []string, defaultFilename string, config interface{}) error {
+	filename := fs.String("config", defaultFilename, "json config file, layered over "+configAllFilename)
+	err := addConfigFlags(fs, reflect.ValueOf(config).Elem(), "")
 	if err != nil {
-		fmt.Println("Error reading config file", filename)
-		fmt.Println("Using default value for", paramName, "=", defaultValue)
-		fmt.Println(err)
-		return defaultValue
+		return err
 	}
-	config := map[string]interface{}{}
-	err = json.Unmarshal(content, &config)
+	err = fs.Parse(args)
 	if err != nil {
-		fmt.Println("JSON error reading config", filename)
-		fmt.Println("Using default value for", paramName, "=", defaultValue)
-		fmt.Println(err)
-		return defaultValue
+		return err
 	}
-	config = extendConfigWithAllDotJson(config)
-	value, exists := config[paramName]
-	if !exists {
-		fmt.Println("Key", paramName, "not found in", filename)
-		fmt.Println("Using default value for", paramName, "=", defaultValue)
-		return defaultValue
+	// the flags have already set their fields, so remember them to set again
+	// after loading the files
+	given := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = f.Value.String()
+	})
+	if _, explicit := given["config"]; explicit {
+		if _, err := os.Stat(*filename); err != nil {
+			return err
+		}
 	}
-	fmt.Println("Configured to use", paramName, "=", value)
-	valueFloat := value.(float64)
-	return int(valueFloat)
-}
-
-func extendConfigWithAllDotJson(config map[string]interface{}) map[string]interface{} {
-	content, err := ioutil.ReadFile("config_all.json")
+	err = LoadConfig(*filename, config)
 	if err != nil {
-		return config
+		return err
 	}
-	allConfig := map[string]interface{}{}
-	err = json.Unmarshal(content, &config)
-	if err != nil {
-		return config
+	for name, value := range given {
+		fs.Set(name, value)
 	}
-	for key := range allConfig {
-		config[key] = allConfig[key]
+	return nil
+}
+
+func addConfigFlags(fs *flag.FlagSet, v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// unexported
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		name = prefix + strings.Replace(name, "_", "-", -1)
+		usage := field.Tag.Get("usage")
+		f := v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			nestedPrefix := name + "-"
+			if field.Anonymous {
+				nestedPrefix = prefix
+			}
+			err := addConfigFlags(fs, f, nestedPrefix)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.CanAddr() || !f.CanSet() {
+			continue
+		}
+		switch p := f.Addr().Interface().(type) {
+		case *int:
+			fs.IntVar(p, name, *p, usage)
+		case *int64:
+			fs.Int64Var(p, name, *p, usage)
+		case *float64:
+			fs.Float64Var(p, name, *p, usage)
+		case *string:
+			fs.StringVar(p, name, *p, usage)
+		case *bool:
+			fs.BoolVar(p, name, *p, usage)
+		default:
+			// lists and other types can only be set in the config file
+		}
 	}
-	return config
+	return nil
 }
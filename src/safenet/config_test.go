@@ -0,0 +1,132 @@
+package safenet
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testNested struct {
+	Rate float64 `json:"rate"`
+}
+
+type testConfig struct {
+	Name   string     `json:"name"`
+	Count  int        `json:"count"`
+	Sizes  []int      `json:"sizes"`
+	Nested testNested `json:"nested"`
+	Params
+}
+
+func writeTestConfig(t *testing.T, dir, name, content string) string {
+	filename := filepath.Join(dir, name)
+	err := ioutil.WriteFile(filename, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoadConfigTypes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := writeTestConfig(t, dir, "config.json", `{
+		"name": "test",
+		"sizes": [1, 2, 3],
+		"nested": {"rate": 0.5},
+		"group_size": 10
+	}`)
+	c := testConfig{Count: 7, Params: DefaultParams()}
+	err = LoadConfig(filename, &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "test" || len(c.Sizes) != 3 || c.Nested.Rate != 0.5 {
+		t.Errorf("Config not filled: %+v", c)
+	}
+	if c.Count != 7 || c.SplitBuffer != 3 {
+		t.Errorf("Defaults not kept: %+v", c)
+	}
+	if c.GroupSize != 10 {
+		t.Errorf("Embedded params not filled: %+v", c.Params)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	unknown := writeTestConfig(t, dir, "unknown.json", `{"nmae": "test"}`)
+	err = LoadConfig(unknown, &testConfig{})
+	if err == nil {
+		t.Error("Unknown key should be an error")
+	}
+	wrongType := writeTestConfig(t, dir, "wrong.json", `{"count": "seven"}`)
+	err = LoadConfig(wrongType, &testConfig{})
+	if err == nil {
+		t.Error("Wrong type should be an error")
+	}
+}
+
+func TestParseConfigFlagsOverrideFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := writeTestConfig(t, dir, "config.json", `{
+		"name": "file",
+		"count": 3,
+		"nested": {"rate": 0.5}
+	}`)
+	c := testConfig{Params: DefaultParams()}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	args := []string{"-config", filename, "-count", "4", "-nested-rate", "0.25", "-group-size", "12"}
+	err = ParseConfig(fs, args, "", &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "file" {
+		t.Errorf("File value not used: %s", c.Name)
+	}
+	if c.Count != 4 || c.Nested.Rate != 0.25 || c.GroupSize != 12 {
+		t.Errorf("Flags did not override file: %+v", c)
+	}
+}
+
+func TestLoadConfigAllIsShared(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	// netsize is for other commands
+	writeTestConfig(t, dir, configAllFilename, `{"name": "all", "count": 1, "netsize": 100}`)
+	writeTestConfig(t, dir, "config.json", `{"count": 2}`)
+	c := testConfig{}
+	err = LoadConfig("config.json", &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "all" || c.Count != 2 {
+		t.Errorf("Config not layered: %+v", c)
+	}
+	writeTestConfig(t, dir, "config.json", `{"netsize": 200}`)
+	err = LoadConfig("config.json", &c)
+	if err == nil {
+		t.Error("Unknown key in the command config should be an error")
+	}
+}
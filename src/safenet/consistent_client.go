@@ -6,12 +6,11 @@ type ConsistentClient struct {
 	ConsistentOperator
 }
 
-func NewConsistentClient() *ConsistentClient {
+func NewConsistentClient(n *Network) *ConsistentClient {
 	c := ConsistentClient{}
-	idBytes := make([]byte, 8)
-	prng.Read(idBytes)
-	c.ConsistentUploader.IdStr = string(idBytes)
+	c.ConsistentUploader.IdStr = newClientId(n)
 	c.ConsistentOperator.Vaults = []*Vault{}
+	c.ConsistentOperator.network = n
 	return &c
 }
 
@@ -37,7 +36,7 @@ func (o *ConsistentOperator) NewVaultsToStart() []*Vault {
 	newVaults := []*Vault{}
 	totalNewVaults := 2
 	for i := 0; i < totalNewVaults; i++ {
-		v := NewVaultForOperator(o)
+		v := NewVaultForOperator(o.network, o)
 		newVaults = append(newVaults, v)
 		o.Vaults = append(o.Vaults, v)
 	}
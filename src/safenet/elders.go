@@ -0,0 +1,143 @@
+package safenet
+
+// Each section keeps its elders, the GroupSize oldest vaults, as state which
+// is updated whenever its vaults change. Every change to an elder set is
+// emitted as an ElderChangeEvent caused by the join, departure, split or
+// merge that changed it. Elder changes are delivered when the event causing
+// them completes, so observers never see a section in the middle of a
+// split or merge.
+
+// Returns the GroupSize oldest vaults, oldest first.
+// see https://forum.safedev.org/t/data-chains-deeper-dive/1209
+func (s *Section) oldestVaults() []*Vault {
+	vaults := s.byAge
+	groupSize := s.network.Params.GroupSize
+	if len(vaults) > groupSize {
+		vaults = vaults[:groupSize]
+	}
+	return append([]*Vault{}, vaults...)
+}
+
+// Recomputes the elder set after the vaults of the section changed. Vaults
+// which were elders of the section before a split or merge and are elders of
+// the new section keep their tenure.
+func (s *Section) updateElders() {
+	n := s.network
+	elders := s.oldestVaults()
+	isElder := map[*Vault]bool{}
+	promoted := []*Vault{}
+	for _, v := range elders {
+		isElder[v] = true
+		if !v.elder {
+			promoted = append(promoted, v)
+		}
+	}
+	// previous elders are either still in the section, or have left it and
+	// are only in the old elder set
+	demoted := []*Vault{}
+	tenures := []int{}
+	previous := append(append([]*Vault{}, s.elderSet...), s.Vaults...)
+	for _, v := range previous {
+		if v.elder && !isElder[v] {
+			v.elder = false
+			demoted = append(demoted, v)
+			tenures = append(tenures, n.steps-v.elderSince)
+		}
+	}
+	for _, v := range promoted {
+		v.elder = true
+		v.elderSince = n.steps
+	}
+	s.elderSet = elders
+	if len(promoted) > 0 || len(demoted) > 0 {
+		n.recordElderChange(s, promoted, demoted, tenures)
+	}
+}
+
+func (n *Network) recordElderChange(s *Section, promoted, demoted []*Vault, tenures []int) {
+	n.eventSeq = n.eventSeq + 1
+	e := &MembershipEvent{
+		Seq:            n.eventSeq,
+		Type:           ElderChangeEvent,
+		Step:           n.steps,
+		PrefixesBefore: []Prefix{},
+		SizesBefore:    []int{},
+		PrefixesAfter:  []Prefix{s.Prefix},
+		SizesAfter:     []int{len(s.Vaults)},
+		Promoted:       []XorName{},
+		Demoted:        []XorName{},
+		Tenures:        tenures,
+	}
+	if len(n.eventCauses) > 0 {
+		e.Cause = n.eventCauses[len(n.eventCauses)-1]
+	}
+	for _, v := range promoted {
+		e.Promoted = append(e.Promoted, v.Name)
+	}
+	for _, v := range demoted {
+		e.Demoted = append(e.Demoted, v.Name)
+	}
+	n.pendingElderChanges = append(n.pendingElderChanges, e)
+}
+
+// Notifies observers of the elder changes recorded so far.
+func (n *Network) deliverElderChanges() {
+	pending := n.pendingElderChanges
+	n.pendingElderChanges = nil
+	for _, e := range pending {
+		n.notifyMembershipEvent(e)
+	}
+}
+
+// An observer collecting elder churn and tenure.
+//
+// Churn counts the elders promoted or demoted in each section by each join,
+// departure and merge, including sections whose elders did not change. The
+// changes from a split are counted against the join causing it. Tenure
+// counts the steps each demoted elder held its role, where a step is one join
+// or departure started by the simulation. Elders still in place at the end
+// are not counted.
+type ElderStats struct {
+	BaseObserver
+	Churn  Histogram
+	Tenure Histogram
+	// elder changes by the seq of the event causing them, then by section
+	pending map[int]map[Prefix]int
+}
+
+// Adds to the given histograms, which can belong to a SeedResult.
+func NewElderStats(churn, tenure Histogram) *ElderStats {
+	return &ElderStats{
+		Churn:   churn,
+		Tenure:  tenure,
+		pending: map[int]map[Prefix]int{},
+	}
+}
+
+func (o *ElderStats) OnElderChange(e *MembershipEvent) {
+	for _, t := range e.Tenures {
+		o.Tenure.Add(t)
+	}
+	changes, exists := o.pending[e.Cause]
+	if !exists {
+		changes = map[Prefix]int{}
+		o.pending[e.Cause] = changes
+	}
+	for _, p := range e.PrefixesAfter {
+		changes[p] = changes[p] + len(e.Promoted) + len(e.Demoted)
+	}
+}
+
+// Elder changes are delivered before the event causing them, so once the
+// event arrives its changes are complete.
+func (o *ElderStats) complete(e *MembershipEvent) {
+	changes := o.pending[e.Seq]
+	delete(o.pending, e.Seq)
+	for _, p := range e.PrefixesAfter {
+		o.Churn.Add(changes[p])
+	}
+}
+
+func (o *ElderStats) OnJoin(e *MembershipEvent)      { o.complete(e) }
+func (o *ElderStats) OnDeparture(e *MembershipEvent) { o.complete(e) }
+func (o *ElderStats) OnMerge(e *MembershipEvent)     { o.complete(e) }
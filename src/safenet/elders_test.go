@@ -0,0 +1,65 @@
+package safenet
+
+import (
+	"testing"
+)
+
+// counts elder promotions and demotions and checks they arrive before the
+// event causing them
+type elderCounter struct {
+	BaseObserver
+	promoted int
+	demoted  int
+	causes   map[int]bool
+	// elder changes without a tenure for every demotion
+	mismatched int
+}
+
+func (c *elderCounter) OnElderChange(e *MembershipEvent) {
+	c.promoted = c.promoted + len(e.Promoted)
+	c.demoted = c.demoted + len(e.Demoted)
+	if len(e.Demoted) != len(e.Tenures) {
+		c.mismatched = c.mismatched + 1
+	}
+	c.causes[e.Cause] = true
+}
+
+func (c *elderCounter) complete(e *MembershipEvent) {
+	delete(c.causes, e.Seq)
+}
+
+func (c *elderCounter) OnJoin(e *MembershipEvent)      { c.complete(e) }
+func (c *elderCounter) OnDeparture(e *MembershipEvent) { c.complete(e) }
+func (c *elderCounter) OnMerge(e *MembershipEvent)     { c.complete(e) }
+
+func TestElderChangesMatchElderSets(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	c := &elderCounter{causes: map[int]bool{}}
+	n.AddObserver(c)
+	stats := NewElderStats(Histogram{}, Histogram{})
+	n.AddObserver(stats)
+	n.SimulateChurn(500, 1500, nil)
+	elders := 0
+	for _, s := range n.Sections() {
+		elders = elders + len(s.elders())
+	}
+	if c.promoted-c.demoted != elders {
+		t.Errorf("%d promotions and %d demotions but %d elders", c.promoted, c.demoted, elders)
+	}
+	if len(c.causes) > 0 {
+		t.Errorf("Elder changes for %d events were not followed by their cause", len(c.causes))
+	}
+	if c.mismatched > 0 {
+		t.Errorf("%d elder changes have the wrong number of tenures", c.mismatched)
+	}
+	demotions := 0
+	for _, count := range stats.Tenure {
+		demotions = demotions + count
+	}
+	if demotions != c.demoted || len(stats.pending) > 0 {
+		t.Errorf("Stats counted %d of %d demotions", demotions, c.demoted)
+	}
+	if len(n.CheckInvariants()) > 0 {
+		t.Error(n.CheckInvariants())
+	}
+}
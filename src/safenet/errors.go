@@ -0,0 +1,79 @@
+package safenet
+
+import (
+	"errors"
+	"sort"
+)
+
+// Problems the network recovers from but which mean the simulation has
+// diverged from the protocol. They are returned by the public API and also
+// counted by the network, so a run can be checked for them afterwards.
+var (
+	ErrNoSectionForVault = errors.New("no section holds the vault")
+	ErrNoSectionForName  = errors.New("no section matches the name")
+	ErrPrefixMismatch    = errors.New("prefix does not match the vault name")
+	ErrEmptySection      = errors.New("section has no vaults")
+	ErrNilOperator       = errors.New("vault has no operator")
+	ErrNoRoute           = errors.New("no neighbour is closer to the destination")
+)
+
+// Counts the error as a warning. Does nothing for nil errors.
+func (n *Network) warn(err error) {
+	if err == nil {
+		return
+	}
+	if n.warnings == nil {
+		n.warnings = map[string]int{}
+	}
+	n.warnings[err.Error()] = n.warnings[err.Error()] + 1
+}
+
+// The number of times each warning happened, keyed by error message.
+func (n *Network) Warnings() map[string]int {
+	warnings := map[string]int{}
+	for message, count := range n.warnings {
+		warnings[message] = count
+	}
+	return warnings
+}
+
+func (n *Network) WarningCount(err error) int {
+	return n.warnings[err.Error()]
+}
+
+func (n *Network) TotalWarnings() int {
+	total := 0
+	for _, count := range n.warnings {
+		total = total + count
+	}
+	return total
+}
+
+const warningsTotal = "warnings"
+
+// Adds the warning counts of the network to the result totals, as
+// "warnings" for the total and "warnings: <message>" for each kind.
+func (r *SeedResult) AddWarnings(n *Network) {
+	r.Totals[warningsTotal] = float64(n.TotalWarnings())
+	for message, count := range n.warnings {
+		r.Totals[warningsTotal+": "+message] = float64(count)
+	}
+}
+
+// Names of the warning totals found in any result, total first
+func WarningNames(results []*SeedResult) []string {
+	found := map[string]bool{}
+	for _, r := range results {
+		for name := range r.Totals {
+			if len(name) > len(warningsTotal) && name[:len(warningsTotal)+1] == warningsTotal+":" {
+				found[name] = true
+			}
+		}
+	}
+	names := []string{}
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{warningsTotal}, names...)
+}
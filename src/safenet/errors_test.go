@@ -0,0 +1,82 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestRemoveVaultNotInNetwork(t *testing.T) {
+	n := buildTestNetwork(1, 300)
+	departures := n.TotalDepartures
+	v := NewVault(n)
+	v.Prefix = n.Sections()[0].Prefix
+	err := n.RemoveVault(v)
+	if err != ErrNoSectionForVault {
+		t.Errorf("Expected ErrNoSectionForVault, got %v", err)
+	}
+	if n.TotalDepartures != departures {
+		t.Error("Failed departure was counted")
+	}
+	if n.WarningCount(ErrNoSectionForVault) != 1 || n.TotalWarnings() != 1 {
+		t.Errorf("Warnings are %v", n.Warnings())
+	}
+	if len(n.CheckInvariants()) > 0 {
+		t.Error(n.CheckInvariants())
+	}
+}
+
+func TestSetPrefixMismatch(t *testing.T) {
+	n := NewNetwork(DefaultParams())
+	v := NewVault(n)
+	p := NewBlankPrefix().extend(!v.Name.GetBit(0))
+	if v.SetPrefix(p) != ErrPrefixMismatch {
+		t.Error("Expected ErrPrefixMismatch")
+	}
+	if v.Prefix.Len() != 0 {
+		t.Error("Prefix changed despite mismatch")
+	}
+}
+
+func TestSectionErrors(t *testing.T) {
+	n := NewNetwork(DefaultParams())
+	s := &Section{network: n}
+	_, err := s.GetRandomVault()
+	if err != ErrEmptySection {
+		t.Errorf("Expected ErrEmptySection, got %v", err)
+	}
+	s.Vaults = []*Vault{NewVault(n)}
+	if s.AllocateSafecoin() != ErrNilOperator {
+		t.Error("Expected ErrNilOperator")
+	}
+}
+
+func TestSplitDropsMismatchedVault(t *testing.T) {
+	n := buildTestNetwork(1, 300)
+	n.RelocationTrigger = NoRelocationTrigger{}
+	s := n.Sections()[0]
+	// give one vault a name outside its section
+	v := s.Vaults[0]
+	s.deleteVault(v)
+	last := s.Prefix.Len() - 1
+	v.Name.SetBit(last, !v.Name.GetBit(last))
+	s.appendVault(v)
+	departures := n.TotalDepartures
+	// add adults until the section splits
+	for i := 0; n.sections.get(s.Prefix) == s; i++ {
+		if i == 1000 {
+			t.Fatal("Section did not split")
+		}
+		w := NewVault(n)
+		w.renameWithPrefix(n, s.Prefix)
+		w.Age = n.Params.AdultAge + 1
+		n.AddVault(w)
+	}
+	if v.registryIndex != -1 || n.TotalDepartures != departures+1 {
+		t.Error("Dropped vault did not depart")
+	}
+	if n.WarningCount(ErrPrefixMismatch) != 1 {
+		t.Errorf("Warnings are %v", n.Warnings())
+	}
+	if len(n.CheckInvariants()) > 0 {
+		t.Error(n.CheckInvariants())
+	}
+}
@@ -0,0 +1,208 @@
+package safenet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Membership events can be written as JSON Lines so cascades, such as a
+// relocation that triggers a merge that triggers another relocation, can be
+// analysed after a run.
+//
+// Every event is given a sequence number when it starts and is written when
+// it completes. Cause is the sequence number of the event that triggered it,
+// or 0 for events started directly by the simulation, so an event's line
+// follows the lines of all the events it caused.
+
+type EventType string
+
+const (
+	JoinEvent       EventType = "join"
+	DepartureEvent  EventType = "departure"
+	SplitEvent      EventType = "split"
+	MergeEvent      EventType = "merge"
+	RelocationEvent EventType = "relocation"
+	// the elders of a section changed
+	ElderChangeEvent EventType = "elder_change"
+)
+
+type MembershipEvent struct {
+	Seq   int       `json:"seq"`
+	Cause int       `json:"cause"`
+	Type  EventType `json:"type"`
+	// the step the event happened in
+	Step int `json:"step"`
+	// the vault joining, departing or being relocated, with its name and age
+	// before the event
+	Vault *XorName `json:"vault,omitempty"`
+	Age   int      `json:"age,omitempty"`
+	// sections involved before the event and their sizes, aligned by index
+	PrefixesBefore []Prefix `json:"prefixes_before"`
+	SizesBefore    []int    `json:"sizes_before"`
+	// sections resulting from the event and their sizes
+	PrefixesAfter []Prefix `json:"prefixes_after"`
+	SizesAfter    []int    `json:"sizes_after"`
+	// for elder changes, the vaults which became or stopped being elders and
+	// how many steps each demoted vault was an elder for
+	Promoted []XorName `json:"promoted,omitempty"`
+	Demoted  []XorName `json:"demoted,omitempty"`
+	Tenures  []int     `json:"tenures,omitempty"`
+	// the estimated cost of this event alone, not of the events it caused
+	MessageCost
+}
+
+func (e *MembershipEvent) setVault(v *Vault) {
+	name := v.Name
+	e.Vault = &name
+	e.Age = v.Age
+}
+
+func (e *MembershipEvent) charge(c MessageCost) {
+	e.MessageCost.add(c)
+}
+
+func (e *MembershipEvent) addBefore(s *Section) {
+	if s == nil {
+		return
+	}
+	e.PrefixesBefore = append(e.PrefixesBefore, s.Prefix)
+	e.SizesBefore = append(e.SizesBefore, len(s.Vaults))
+}
+
+func (e *MembershipEvent) addAfter(s *Section) {
+	if s == nil {
+		return
+	}
+	e.PrefixesAfter = append(e.PrefixesAfter, s.Prefix)
+	e.SizesAfter = append(e.SizesAfter, len(s.Vaults))
+}
+
+// An observer writing every membership event to a writer as one JSON object
+// per line.
+type EventTrace struct {
+	BaseObserver
+	encoder *json.Encoder
+	err     error
+}
+
+func NewEventTrace(w io.Writer) *EventTrace {
+	return &EventTrace{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// Returns the first error encountered writing the trace, if any.
+func (t *EventTrace) Err() error {
+	return t.err
+}
+
+func (t *EventTrace) write(e *MembershipEvent) {
+	if t.err == nil {
+		t.err = t.encoder.Encode(e)
+	}
+}
+
+func (t *EventTrace) OnJoin(e *MembershipEvent)        { t.write(e) }
+func (t *EventTrace) OnDeparture(e *MembershipEvent)   { t.write(e) }
+func (t *EventTrace) OnSplit(e *MembershipEvent)       { t.write(e) }
+func (t *EventTrace) OnMerge(e *MembershipEvent)       { t.write(e) }
+func (t *EventTrace) OnRelocate(e *MembershipEvent)    { t.write(e) }
+func (t *EventTrace) OnElderChange(e *MembershipEvent) { t.write(e) }
+
+// Writes every membership event to w, replacing any trace set previously.
+// Pass nil to stop tracing.
+func (n *Network) SetEventTrace(w io.Writer) {
+	if n.trace != nil {
+		n.RemoveObserver(n.traceId)
+		n.trace = nil
+	}
+	if w == nil {
+		return
+	}
+	n.trace = NewEventTrace(w)
+	n.traceId = n.AddObserver(n.trace)
+}
+
+// Returns the first error encountered writing the event trace, if any.
+func (n *Network) EventTraceError() error {
+	if n.trace == nil {
+		return nil
+	}
+	return n.trace.Err()
+}
+
+// Starts a new event caused by the event currently in progress.
+func (n *Network) beginEvent(t EventType) *MembershipEvent {
+	n.eventSeq = n.eventSeq + 1
+	e := &MembershipEvent{
+		Seq:            n.eventSeq,
+		Type:           t,
+		Step:           n.steps,
+		PrefixesBefore: []Prefix{},
+		SizesBefore:    []int{},
+		PrefixesAfter:  []Prefix{},
+		SizesAfter:     []int{},
+	}
+	if len(n.eventCauses) > 0 {
+		e.Cause = n.eventCauses[len(n.eventCauses)-1]
+	}
+	n.eventCauses = append(n.eventCauses, e.Seq)
+	return e
+}
+
+// Completes the event, which must be the most recently started event, and
+// notifies observers.
+func (n *Network) endEvent(e *MembershipEvent) {
+	n.eventCauses = n.eventCauses[:len(n.eventCauses)-1]
+	n.checkInvariantsAfter(e)
+	n.deliverElderChanges()
+	n.notifyMembershipEvent(e)
+}
+
+func (p Prefix) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.BinaryString())
+}
+
+func (x XorName) MarshalJSON() ([]byte, error) {
+	return json.Marshal(x.Hex())
+}
+
+// Traces can be read back into MembershipEvents.
+func (p *Prefix) UnmarshalJSON(data []byte) error {
+	var bits string
+	err := json.Unmarshal(data, &bits)
+	if err != nil {
+		return err
+	}
+	if len(bits) > xornameBits {
+		return fmt.Errorf("prefix %s is longer than %d bits", bits, xornameBits)
+	}
+	q := NewBlankPrefix()
+	for _, b := range bits {
+		if b != '0' && b != '1' {
+			return fmt.Errorf("prefix %s is not binary", bits)
+		}
+		q = q.extend(b == '1')
+	}
+	*p = q
+	return nil
+}
+
+func (x *XorName) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(b) != xornameBytes {
+		return fmt.Errorf("name %s is not %d bytes", s, xornameBytes)
+	}
+	copy(x[:], b)
+	return nil
+}
@@ -0,0 +1,54 @@
+package safenet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestEventTraceRoundTrip(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	var trace bytes.Buffer
+	n.SetEventTrace(&trace)
+	n.SimulateChurn(100, 300, nil)
+	n.SetEventTrace(nil)
+	if n.EventTraceError() != nil {
+		t.Fatal(n.EventTraceError())
+	}
+	seen := map[int]bool{}
+	types := map[EventType]int{}
+	lines := bufio.NewScanner(&trace)
+	lines.Buffer(nil, 1<<20)
+	for lines.Scan() {
+		e := MembershipEvent{}
+		err := json.Unmarshal(lines.Bytes(), &e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := json.Marshal(&e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, lines.Bytes()) {
+			t.Fatalf("Trace line changed when read back:\n%s\n%s", lines.Bytes(), again)
+		}
+		// events follow every event they caused
+		if seen[e.Cause] {
+			t.Errorf("Event %d follows its cause %d", e.Seq, e.Cause)
+		}
+		seen[e.Seq] = true
+		types[e.Type] = types[e.Type] + 1
+	}
+	if types[JoinEvent] != n.TotalJoins || types[SplitEvent] != n.TotalSplits || types[RelocationEvent] != n.TotalRelocations {
+		t.Errorf("Traced %v", types)
+	}
+	var p Prefix
+	if json.Unmarshal([]byte(`"0120"`), &p) == nil {
+		t.Error("Expected an error for a prefix which is not binary")
+	}
+	var x XorName
+	if json.Unmarshal([]byte(`"abcd"`), &x) == nil {
+		t.Error("Expected an error for a short name")
+	}
+}
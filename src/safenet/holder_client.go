@@ -6,12 +6,11 @@ type HolderClient struct {
 	HolderOperator
 }
 
-func NewHolderClient() *HolderClient {
+func NewHolderClient(n *Network) *HolderClient {
 	c := HolderClient{}
-	idBytes := make([]byte, 8)
-	prng.Read(idBytes)
-	c.HolderUploader.IdStr = string(idBytes)
+	c.HolderUploader.IdStr = newClientId(n)
 	c.HolderOperator.Vaults = []*Vault{}
+	c.HolderOperator.network = n
 	return &c
 }
 
@@ -6,38 +6,41 @@ type InconsistentClient struct {
 	InconsistentOperator
 }
 
-func NewInconsistentClient() *InconsistentClient {
+func NewInconsistentClient(n *Network) *InconsistentClient {
 	c := InconsistentClient{}
-	idBytes := make([]byte, 8)
-	prng.Read(idBytes)
-	c.InconsistentUploader.IdStr = string(idBytes)
+	c.InconsistentUploader.IdStr = newClientId(n)
 	c.InconsistentUploader.PutHistory = []float64{}
+	c.InconsistentUploader.network = n
 	c.InconsistentDownloader.GetHistory = []float64{}
+	c.InconsistentDownloader.network = n
 	c.InconsistentOperator.Vaults = []*Vault{}
+	c.InconsistentOperator.network = n
 	return &c
 }
 
 type InconsistentUploader struct {
 	UniversalUploader
 	PutHistory []float64
+	network    *Network
 }
 
 func (i InconsistentUploader) MbPutForDay(day int) float64 {
 	maxPutsPerDay := 20
 	for len(i.PutHistory) <= day {
-		i.PutHistory = append(i.PutHistory, float64(prng.Intn(maxPutsPerDay)))
+		i.PutHistory = append(i.PutHistory, float64(i.network.rng.clients.Intn(maxPutsPerDay)))
 	}
 	return i.PutHistory[day]
 }
 
 type InconsistentDownloader struct {
 	GetHistory []float64
+	network    *Network
 }
 
 func (i *InconsistentDownloader) MbGetForDay(day int) float64 {
 	maxGetsPerDay := 2000
 	for len(i.GetHistory) <= day {
-		i.GetHistory = append(i.GetHistory, float64(prng.Intn(maxGetsPerDay)))
+		i.GetHistory = append(i.GetHistory, float64(i.network.rng.clients.Intn(maxGetsPerDay)))
 	}
 	return i.GetHistory[day]
 }
@@ -48,9 +51,9 @@ type InconsistentOperator struct {
 
 func (o *InconsistentOperator) NewVaultsToStart() []*Vault {
 	newVaults := []*Vault{}
-	totalNewVaults := prng.Intn(4) + 1
+	totalNewVaults := o.network.rng.clients.Intn(4) + 1
 	for i := 0; i < totalNewVaults; i++ {
-		v := NewVaultForOperator(o)
+		v := NewVaultForOperator(o.network, o)
 		newVaults = append(newVaults, v)
 		o.Vaults = append(o.Vaults, v)
 	}
@@ -61,7 +64,7 @@ func (o *InconsistentOperator) ExistingVaultsToStop() []*Vault {
 	if len(o.Vaults) == 0 {
 		return []*Vault{}
 	}
-	i := prng.Intn(len(o.Vaults))
+	i := o.network.rng.clients.Intn(len(o.Vaults))
 	if i == 0 {
 		return []*Vault{}
 	}
@@ -0,0 +1,213 @@
+package safenet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The split, merge and relocate code can leave the network in a state that
+// still runs but gives wrong results, so the network can check its own
+// consistency, either on demand or after every membership event.
+
+// A broken invariant
+type Violation struct {
+	Invariant string
+	Detail    string
+}
+
+func (v Violation) String() string {
+	return v.Invariant + ": " + v.Detail
+}
+
+// Raised as a panic when Debug is set and an event leaves the network
+// inconsistent.
+type InvariantError struct {
+	Event      *MembershipEvent
+	Violations []Violation
+}
+
+func (e *InvariantError) Error() string {
+	lines := []string{}
+	for _, v := range e.Violations {
+		lines = append(lines, v.String())
+	}
+	return fmt.Sprintf("%d invariants broken by %s event %d\n%s", len(e.Violations), e.Event.Type, e.Event.Seq, strings.Join(lines, "\n"))
+}
+
+// Returns every broken invariant, or an empty list if the network is
+// consistent.
+func (n *Network) CheckInvariants() []Violation {
+	c := invariantChecker{
+		network: n,
+		owners:  map[*Vault]*Section{},
+	}
+	c.checkPrefixes()
+	c.checkSections()
+	c.checkCounters()
+	return c.violations
+}
+
+type invariantChecker struct {
+	network    *Network
+	violations []Violation
+	// the section each vault was found in
+	owners       map[*Vault]*Section
+	sectionCount int
+}
+
+func (c *invariantChecker) add(invariant string, format string, a ...interface{}) {
+	c.violations = append(c.violations, Violation{invariant, fmt.Sprintf(format, a...)})
+}
+
+// Section prefixes cover the whole namespace with no overlap.
+func (c *invariantChecker) checkPrefixes() {
+	if c.network.sections.len() == 0 {
+		if c.network.TotalVaults() > 0 {
+			c.add("coverage", "%d vaults but no sections", c.network.TotalVaults())
+		}
+		return
+	}
+	c.checkNode(c.network.sections.root, NewBlankPrefix(), false)
+}
+
+// Checks the sections at and below the node, where covered is set if an
+// overlapping section above already covers the node.
+func (c *invariantChecker) checkNode(node *trieNode, p Prefix, covered bool) {
+	hasChildren := node.children[0] != nil || node.children[1] != nil
+	if node.section != nil {
+		c.sectionCount = c.sectionCount + 1
+		if !node.section.Prefix.Equals(p) {
+			c.add("trie", "section %s stored at %s", node.section.Prefix.BinaryString(), p.BinaryString())
+		}
+		if hasChildren {
+			c.add("overlap", "section %s has sections below it", p.BinaryString())
+		}
+		covered = true
+	} else if !hasChildren && !covered {
+		c.add("coverage", "no section for %s", p.BinaryString())
+	}
+	for i, child := range node.children {
+		childPrefix := p.extend(i == 1)
+		if child != nil {
+			c.checkNode(child, childPrefix, covered)
+		} else if hasChildren && node.section == nil && !covered {
+			c.add("coverage", "no section for %s", childPrefix.BinaryString())
+		}
+	}
+}
+
+// Vaults match their section, are in one section only, are registered, and
+// hold only chunks for their prefix. Each section's age index holds its
+// vaults in order and its elders are its oldest vaults.
+func (c *invariantChecker) checkSections() {
+	n := c.network
+	for _, s := range n.sections.all() {
+		if len(s.Vaults) == 0 && n.sections.len() > 1 {
+			c.add("empty", "section %s has no vaults", s.Prefix.BinaryString())
+		}
+		if s.network != n {
+			c.add("network", "section %s belongs to another network", s.Prefix.BinaryString())
+		}
+		c.checkAgeIndex(s)
+		c.checkElders(s)
+		for _, v := range s.Vaults {
+			name := v.Name.Hex()
+			if other, exists := c.owners[v]; exists {
+				c.add("duplicate", "vault %s is in sections %s and %s", name, other.Prefix.BinaryString(), s.Prefix.BinaryString())
+				continue
+			}
+			c.owners[v] = s
+			if !v.Prefix.Equals(s.Prefix) {
+				c.add("vault prefix", "vault %s has prefix %s in section %s", name, v.Prefix.BinaryString(), s.Prefix.BinaryString())
+			}
+			if !s.Prefix.Matches(v.Name) {
+				c.add("vault name", "vault %s does not match section %s", name, s.Prefix.BinaryString())
+			}
+			if v.network != n {
+				c.add("network", "vault %s belongs to another network", name)
+			}
+			i := v.registryIndex
+			if i < 0 || i >= n.vaults.len() || n.vaults.vaults[i] != v {
+				c.add("registry", "vault %s in section %s is not registered", name, s.Prefix.BinaryString())
+			}
+			for _, chunk := range v.Chunks {
+				if !v.Prefix.Matches(chunk) {
+					c.add("chunk", "vault %s with prefix %s holds chunk %s", name, v.Prefix.BinaryString(), chunk.Hex())
+				}
+			}
+		}
+	}
+	for _, v := range n.vaults.vaults {
+		if _, exists := c.owners[v]; !exists {
+			c.add("registry", "registered vault %s is in no section", v.Name.Hex())
+		}
+	}
+}
+
+func (c *invariantChecker) checkAgeIndex(s *Section) {
+	expected := append([]*Vault{}, s.Vaults...)
+	sort.Sort(oldestFirst(expected))
+	same := len(expected) == len(s.byAge)
+	for i := 0; same && i < len(expected); i++ {
+		same = expected[i] == s.byAge[i]
+	}
+	if !same {
+		c.add("age index", "section %s has %d vaults but its age index does not hold them oldest first", s.Prefix.BinaryString(), len(s.Vaults))
+	}
+}
+
+func (c *invariantChecker) checkElders(s *Section) {
+	expected := s.oldestVaults()
+	same := len(expected) == len(s.elderSet)
+	for i := 0; same && i < len(expected); i++ {
+		same = expected[i] == s.elderSet[i]
+	}
+	if !same {
+		c.add("elders", "section %s has %d elders which are not its %d oldest vaults", s.Prefix.BinaryString(), len(s.elderSet), len(expected))
+	}
+	isElder := map[*Vault]bool{}
+	for _, v := range s.elderSet {
+		isElder[v] = true
+	}
+	for _, v := range s.Vaults {
+		if v.elder != isElder[v] {
+			c.add("elders", "vault %s in section %s is marked elder %t", v.Name.Hex(), s.Prefix.BinaryString(), v.elder)
+		}
+	}
+}
+
+// Counters kept incrementally agree with the state they count.
+func (c *invariantChecker) checkCounters() {
+	n := c.network
+	if c.sectionCount != n.sections.len() {
+		c.add("counter", "%d sections counted but %d found", n.sections.len(), c.sectionCount)
+	}
+	if len(c.owners) != n.vaults.len() {
+		c.add("counter", "%d vaults registered but %d in sections", n.vaults.len(), len(c.owners))
+	}
+	if n.TotalJoins-n.TotalDepartures != n.vaults.len() {
+		c.add("counter", "%d joins and %d departures but %d vaults", n.TotalJoins, n.TotalDepartures, n.vaults.len())
+	}
+	if len(n.NeighbourhoodHops) != n.TotalRelocations {
+		c.add("counter", "%d relocations but %d hops recorded", n.TotalRelocations, len(n.NeighbourhoodHops))
+	}
+	var coins int32
+	for _, client := range n.Clients {
+		coins = coins + client.TotalSafecoins()
+	}
+	if coins != n.totalSafecoins {
+		c.add("counter", "%d safecoins counted but clients hold %d", n.totalSafecoins, coins)
+	}
+}
+
+// Checks the invariants once the event is complete when Debug is set.
+func (n *Network) checkInvariantsAfter(e *MembershipEvent) {
+	if !n.Debug {
+		return
+	}
+	violations := n.CheckInvariants()
+	if len(violations) > 0 {
+		panic(&InvariantError{e, violations})
+	}
+}
@@ -0,0 +1,111 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestInvariantsHoldAfterEveryEvent(t *testing.T) {
+	n := NewNetworkFromSeed(3, DefaultParams())
+	n.Debug = true
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatal(r)
+		}
+	}()
+	n.SimulateChurn(300, 1000, nil)
+	if violations := n.CheckInvariants(); len(violations) > 0 {
+		t.Error(violations)
+	}
+}
+
+func TestInvariantsFindCorruption(t *testing.T) {
+	n := buildTestNetwork(1, 300)
+	if violations := n.CheckInvariants(); len(violations) > 0 {
+		t.Fatal(violations)
+	}
+	sections := n.Sections()
+	// move a vault into a second section without updating anything else
+	v := sections[0].Vaults[0]
+	sections[1].Vaults = append(sections[1].Vaults, v)
+	// and drop a section
+	n.sections.remove(sections[2].Prefix)
+	found := map[string]bool{}
+	for _, violation := range n.CheckInvariants() {
+		found[violation.Invariant] = true
+	}
+	for _, invariant := range []string{"duplicate", "coverage", "registry", "counter"} {
+		if !found[invariant] {
+			t.Errorf("Expected %s violation", invariant)
+		}
+	}
+}
+
+func TestInvariantsPanicInDebugMode(t *testing.T) {
+	n := buildTestNetwork(1, 300)
+	n.Debug = true
+	// give a vault a chunk it shouldn't hold, so the next event fails
+	s := n.Sections()[0]
+	chunk := NewXorName(n)
+	chunk.SetBit(0, !s.Prefix.Bit(0))
+	s.Vaults[0].Chunks = append(s.Vaults[0].Chunks, chunk)
+	defer func() {
+		r := recover()
+		err, isInvariantError := r.(*InvariantError)
+		if !isInvariantError {
+			t.Fatalf("Expected InvariantError, got %v", r)
+		}
+		if err.Violations[0].Invariant != "chunk" {
+			t.Errorf("Unexpected violation %s", err.Violations[0])
+		}
+	}()
+	n.AddVault(NewVault(n))
+}
+
+func TestCountersMatchRecount(t *testing.T) {
+	n := NewNetworkFromSeed(2, DefaultParams())
+	for i := 0; i < 20; i++ {
+		c := NewRandomClient(n)
+		c.AllocateSafecoins(100)
+		n.AddClient(c)
+	}
+	// client activity as in the safecoin simulation
+	for day := 1; day < 30; day++ {
+		for _, c := range n.Clients {
+			for _, v := range c.NewVaultsToStart() {
+				n.AddVault(v)
+			}
+			for _, v := range c.ExistingVaultsToStop() {
+				n.RemoveVault(v)
+			}
+			c.ConvertCoinsToPutBalance(day, c, n)
+			for p := 0.0; p < c.MbPutForDay(day); p++ {
+				n.DoRandomPut(c, c)
+			}
+			for g := 0.0; g < c.MbGetForDay(day); g++ {
+				n.DoRandomGet()
+			}
+		}
+	}
+	vaults := 0
+	for _, s := range n.Sections() {
+		vaults = vaults + len(s.Vaults)
+	}
+	var coins int32
+	for _, c := range n.Clients {
+		coins = coins + c.TotalSafecoins()
+	}
+	if vaults != n.TotalVaults() || len(n.Sections()) != n.TotalSections() || coins != n.TotalSafecoins() {
+		t.Errorf("Counted %d vaults, %d sections and %d coins but recounted %d, %d and %d", n.TotalVaults(), n.TotalSections(), n.TotalSafecoins(), vaults, len(n.Sections()), coins)
+	}
+	if vaults == 0 || coins == 2000 {
+		t.Error("Expected vaults to join and coins to change")
+	}
+	if violations := n.CheckInvariants(); len(violations) > 0 {
+		t.Error(violations)
+	}
+	// changing coins outside the network is found by the invariants
+	n.Clients[0].AllocateSafecoins(1)
+	if len(n.CheckInvariants()) == 0 {
+		t.Error("Expected the safecoin counter to drift")
+	}
+}
@@ -0,0 +1,200 @@
+package safenet
+
+import (
+	"fmt"
+	"io"
+)
+
+// Membership changes are paid for in messages. The elders of a changed
+// section agree on the change and tell the neighbouring sections, relocated
+// vaults are passed from the elders of one section to the elders of another,
+// and vaults which become responsible for chunks must be sent them. Every
+// membership event is charged an estimate of these messages, see CostModel,
+// so the overhead of node ageing can be compared between policies.
+
+// Estimates the messages sent for membership events. Elder counts and
+// neighbours are taken from the sections involved when the event happens.
+//
+//   - a join, departure, split or merge changes sections, and each changed
+//     section with e elders and k neighbours costs
+//     AgreementRounds * e * (e-1) + NeighbourUpdates * e * k messages
+//   - a relocation costs one message from each elder of the old section to each
+//     elder of the new section for every neighbourhood hop between them, plus
+//     the departure and join it causes
+//   - each chunk sent to a vault is one message carrying ChunkBytes, which
+//     happens when a vault joins a section holding chunks and when a merge
+//     gives vaults the chunks of their new siblings
+type CostModel struct {
+	MessageBytes     int `json:"message_bytes" usage:"bytes in a message which carries no chunk"`
+	ChunkBytes       int `json:"chunk_bytes" usage:"bytes in a chunk, sent in addition to the message bytes"`
+	AgreementRounds  int `json:"agreement_rounds" usage:"rounds of votes between every pair of elders to agree on a section change"`
+	NeighbourUpdates int `json:"neighbour_updates" usage:"messages each elder sends to each neighbour section when its section changes"`
+}
+
+// Chunks are 1 MB, as in the storage calculations.
+func DefaultCostModel() CostModel {
+	return CostModel{
+		MessageBytes:     1024,
+		ChunkBytes:       1024 * 1024,
+		AgreementRounds:  1,
+		NeighbourUpdates: 1,
+	}
+}
+
+type MessageCost struct {
+	Messages int `json:"messages"`
+	Bytes    int `json:"bytes"`
+}
+
+func (c *MessageCost) add(other MessageCost) {
+	c.Messages = c.Messages + other.Messages
+	c.Bytes = c.Bytes + other.Bytes
+}
+
+func (m CostModel) messages(count int) MessageCost {
+	return MessageCost{count, count * m.MessageBytes}
+}
+
+func (m CostModel) chunks(count int) MessageCost {
+	return MessageCost{count, count * (m.MessageBytes + m.ChunkBytes)}
+}
+
+// The cost for the elders of a section to agree on a change to it and tell
+// its neighbours.
+func (n *Network) sectionChangeCost(s *Section) MessageCost {
+	elders := len(s.elders())
+	neighbours := len(n.neighbourSections(s.Prefix))
+	m := n.CostModel
+	return m.messages(m.AgreementRounds*elders*(elders-1) + m.NeighbourUpdates*elders*neighbours)
+}
+
+// The cost of passing a relocated vault between sections, at least one hop
+// even when the vault stays in its section.
+func (n *Network) relocationCost(from, to *Section) MessageCost {
+	hops := to.Prefix.DifferingBits(from.Prefix)
+	if hops < 1 {
+		hops = 1
+	}
+	return n.CostModel.messages(len(from.elders()) * len(to.elders()) * hops)
+}
+
+// The cost of giving every vault in the merging sections the chunks of the
+// other sections.
+func (n *Network) mergeChunkCost(merging []*Section) MessageCost {
+	total := 0
+	for _, s := range merging {
+		total = total + s.chunkCount()
+	}
+	moved := 0
+	for _, s := range merging {
+		moved = moved + len(s.Vaults)*(total-s.chunkCount())
+	}
+	return n.CostModel.chunks(moved)
+}
+
+// Every vault holds every chunk of its section, see addVault.
+func (s *Section) chunkCount() int {
+	if len(s.Vaults) == 0 {
+		return 0
+	}
+	return len(s.Vaults[0].Chunks)
+}
+
+// An observer totalling message costs by event type and over time.
+//
+// Events are charged only their own cost, eg the join caused by a relocation
+// is counted as a join. Relocation cascades are also totalled separately,
+// each cascade being a relocation and every event it caused, directly or
+// not, such as merges and further relocations.
+type MessageStats struct {
+	BaseObserver
+	Totals map[EventType]MessageCost
+	// messages and bytes of the events in each interval of steps, keyed by
+	// the first step of the interval
+	Messages Histogram
+	Bytes    Histogram
+	Interval int
+	Cascades MessageCost
+	// costs of completed events by the seq of the event causing them
+	pending map[int]cascadeCost
+}
+
+type cascadeCost struct {
+	// every event caused
+	caused MessageCost
+	// events caused within a relocation
+	relocations MessageCost
+}
+
+// Adds to the given histograms, which can belong to a SeedResult.
+func NewMessageStats(interval int, messages, bytes Histogram) *MessageStats {
+	return &MessageStats{
+		Totals:   map[EventType]MessageCost{},
+		Messages: messages,
+		Bytes:    bytes,
+		Interval: interval,
+		pending:  map[int]cascadeCost{},
+	}
+}
+
+// Events are delivered after the events they cause, so the cost of
+// everything an event caused is known once it arrives.
+func (o *MessageStats) complete(e *MembershipEvent) {
+	total := o.Totals[e.Type]
+	total.add(e.MessageCost)
+	o.Totals[e.Type] = total
+	if o.Interval > 0 {
+		step := e.Step / o.Interval * o.Interval
+		o.Messages[step] = o.Messages[step] + e.Messages
+		o.Bytes[step] = o.Bytes[step] + e.Bytes
+	}
+	c := o.pending[e.Seq]
+	delete(o.pending, e.Seq)
+	c.caused.add(e.MessageCost)
+	if e.Type == RelocationEvent {
+		c.relocations = c.caused
+	}
+	if e.Cause == 0 {
+		o.Cascades.add(c.relocations)
+		return
+	}
+	parent := o.pending[e.Cause]
+	parent.caused.add(c.caused)
+	parent.relocations.add(c.relocations)
+	o.pending[e.Cause] = parent
+}
+
+func (o *MessageStats) OnJoin(e *MembershipEvent)      { o.complete(e) }
+func (o *MessageStats) OnDeparture(e *MembershipEvent) { o.complete(e) }
+func (o *MessageStats) OnSplit(e *MembershipEvent)     { o.complete(e) }
+func (o *MessageStats) OnMerge(e *MembershipEvent)     { o.complete(e) }
+func (o *MessageStats) OnRelocate(e *MembershipEvent)  { o.complete(e) }
+
+var costedEvents = []EventType{JoinEvent, DepartureEvent, RelocationEvent, SplitEvent, MergeEvent}
+
+// Adds the message totals of the observer to the result totals
+func (r *SeedResult) AddMessageStats(o *MessageStats) {
+	all := MessageCost{}
+	for _, t := range costedEvents {
+		c := o.Totals[t]
+		r.Totals[fmt.Sprintf("%s messages", t)] = float64(c.Messages)
+		r.Totals[fmt.Sprintf("%s bytes", t)] = float64(c.Bytes)
+		all.add(c)
+	}
+	r.Totals["total messages"] = float64(all.Messages)
+	r.Totals["total bytes"] = float64(all.Bytes)
+	r.Totals["relocation cascade messages"] = float64(o.Cascades.Messages)
+	r.Totals["relocation cascade bytes"] = float64(o.Cascades.Bytes)
+}
+
+// Prints the message totals added by AddMessageStats
+func PrintMessageStats(w io.Writer, results []*SeedResult) {
+	for _, t := range costedEvents {
+		PrintTotalStats(w, results, fmt.Sprintf("%s messages", t))
+		PrintTotalStats(w, results, fmt.Sprintf("%s bytes", t))
+	}
+	PrintTotalStats(w, results, "total messages")
+	PrintTotalStats(w, results, "total bytes")
+	PrintTotalStats(w, results, "relocation cascade messages")
+	PrintTotalStats(w, results, "relocation cascade bytes")
+}
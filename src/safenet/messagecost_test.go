@@ -0,0 +1,64 @@
+package safenet
+
+import (
+	"testing"
+)
+
+// sums the cost of every event as it arrives
+type costCounter struct {
+	BaseObserver
+	total MessageCost
+}
+
+func (c *costCounter) OnJoin(e *MembershipEvent)      { c.total.add(e.MessageCost) }
+func (c *costCounter) OnDeparture(e *MembershipEvent) { c.total.add(e.MessageCost) }
+func (c *costCounter) OnSplit(e *MembershipEvent)     { c.total.add(e.MessageCost) }
+func (c *costCounter) OnMerge(e *MembershipEvent)     { c.total.add(e.MessageCost) }
+func (c *costCounter) OnRelocate(e *MembershipEvent)  { c.total.add(e.MessageCost) }
+
+func TestMessageStats(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	c := &costCounter{}
+	n.AddObserver(c)
+	stats := NewMessageStats(100, Histogram{}, Histogram{})
+	n.AddObserver(stats)
+	n.SimulateChurn(300, 1000, nil)
+	total := MessageCost{}
+	for _, cost := range stats.Totals {
+		total.add(cost)
+	}
+	if total != c.total || total.Messages == 0 {
+		t.Errorf("Stats total %+v but events cost %+v", total, c.total)
+	}
+	messages := 0
+	for _, m := range stats.Messages {
+		messages = messages + m
+	}
+	if messages != total.Messages {
+		t.Errorf("%d messages over time but %d in total", messages, total.Messages)
+	}
+	if stats.Cascades.Messages <= stats.Totals[RelocationEvent].Messages || stats.Cascades.Messages > total.Messages {
+		t.Errorf("Relocation cascades cost %d messages of %d", stats.Cascades.Messages, total.Messages)
+	}
+	if len(stats.pending) > 0 {
+		t.Errorf("Costs of %d events were not followed by their cause", len(stats.pending))
+	}
+}
+
+func TestSectionChangeCost(t *testing.T) {
+	n := NewNetwork(DefaultParams())
+	for i := 0; i < 5; i++ {
+		n.AddVault(NewVault(n))
+	}
+	s := n.Sections()[0]
+	// 5 elders voting with each other and no neighbours
+	cost := n.sectionChangeCost(s)
+	if cost.Messages != 20 || cost.Bytes != 20*n.CostModel.MessageBytes {
+		t.Errorf("Expected 20 messages, got %+v", cost)
+	}
+	n.CostModel.NeighbourUpdates = 0
+	n.CostModel.AgreementRounds = 2
+	if n.sectionChangeCost(s).Messages != 40 {
+		t.Error("Expected two rounds of votes")
+	}
+}
@@ -0,0 +1,243 @@
+package safenet
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// A single seed of a large network is not enough to draw conclusions from, so
+// simulations can be run over many seeds concurrently and the resulting
+// histograms merged into per-bucket statistics.
+
+type Histogram map[int]int
+
+func (h Histogram) Add(key int) {
+	h[key] = h[key] + 1
+}
+
+func (h Histogram) Keys() []int {
+	keys := []int{}
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Sort(sort.IntSlice(keys))
+	return keys
+}
+
+// The output of simulating a single seed.
+// Histograms are keyed by name, eg "size" or "age", and totals are single
+// values such as "total splits".
+type SeedResult struct {
+	Seed       int64
+	Histograms map[string]Histogram
+	Totals     map[string]float64
+}
+
+func NewSeedResult(seed int64) *SeedResult {
+	return &SeedResult{
+		Seed:       seed,
+		Histograms: map[string]Histogram{},
+		Totals:     map[string]float64{},
+	}
+}
+
+func (r *SeedResult) Histogram(name string) Histogram {
+	h, exists := r.Histograms[name]
+	if !exists {
+		h = Histogram{}
+		r.Histograms[name] = h
+	}
+	return h
+}
+
+// Simulates one seed, calling progress with values from 0 to 1 as the
+// simulation proceeds.
+type SeedRun func(seed int64, progress func(float64)) *SeedResult
+
+// Runs totalSeeds consecutive seeds starting from firstSeed using one worker
+// per core. Results are returned in seed order regardless of the order they
+// complete in. Overall progress is written to progress, which may be nil.
+func RunSeeds(firstSeed int64, totalSeeds int, progress io.Writer, run SeedRun) []*SeedResult {
+	results := make([]*SeedResult, totalSeeds)
+	runJobs(totalSeeds, progress, func(i int, progress func(float64)) {
+		results[i] = run(firstSeed+int64(i), progress)
+	})
+	return results
+}
+
+// Runs jobs 0 to totalJobs-1 using one worker per core, writing overall
+// progress to w unless it is nil.
+func runJobs(totalJobs int, w io.Writer, run func(job int, progress func(float64))) {
+	if w == nil {
+		w = ioutil.Discard
+	}
+	progresses := make([]float64, totalJobs)
+	lastPct := -1
+	var mutex sync.Mutex
+	reportProgress := func(i int, p float64) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		progresses[i] = p
+		sum := 0.0
+		for _, q := range progresses {
+			sum = sum + q
+		}
+		pct := int(sum / float64(totalJobs) * 100.0)
+		if pct != lastPct {
+			lastPct = pct
+			fmt.Fprint(w, "   ", pct, "%\r")
+		}
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	workers := runtime.NumCPU()
+	if workers > totalJobs {
+		workers = totalJobs
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				i := i
+				run(i, func(p float64) {
+					reportProgress(i, p)
+				})
+				reportProgress(i, 1)
+			}
+		}()
+	}
+	for i := 0; i < totalJobs; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if lastPct != 100 {
+		fmt.Fprint(w, "   100%")
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w)
+}
+
+// Mean, standard deviation and 95% confidence interval of the mean for a
+// set of values, one value per seed.
+type Summary struct {
+	Mean   float64
+	StdDev float64
+	CILow  float64
+	CIHigh float64
+}
+
+func Summarise(values []float64) Summary {
+	s := Summary{}
+	total := len(values)
+	if total == 0 {
+		return s
+	}
+	for _, v := range values {
+		s.Mean = s.Mean + v
+	}
+	s.Mean = s.Mean / float64(total)
+	s.CILow = s.Mean
+	s.CIHigh = s.Mean
+	if total < 2 {
+		return s
+	}
+	// sample standard deviation
+	sumSq := 0.0
+	for _, v := range values {
+		sumSq = sumSq + (v-s.Mean)*(v-s.Mean)
+	}
+	s.StdDev = math.Sqrt(sumSq / float64(total-1))
+	halfWidth := tCritical95(total-1) * s.StdDev / math.Sqrt(float64(total))
+	s.CILow = s.Mean - halfWidth
+	s.CIHigh = s.Mean + halfWidth
+	return s
+}
+
+type BucketStats struct {
+	Bucket int
+	Summary
+}
+
+// Merges the same histogram from several seeds. A bucket missing from one
+// seed is counted as zero for that seed.
+func SummariseHistograms(histograms []Histogram) []BucketStats {
+	merged := Histogram{}
+	for _, h := range histograms {
+		for k := range h {
+			merged[k] = 0
+		}
+	}
+	stats := []BucketStats{}
+	for _, k := range merged.Keys() {
+		values := make([]float64, len(histograms))
+		for i, h := range histograms {
+			values[i] = float64(h[k])
+		}
+		stats = append(stats, BucketStats{k, Summarise(values)})
+	}
+	return stats
+}
+
+// Gets the named histogram from every result
+func HistogramsNamed(results []*SeedResult, name string) []Histogram {
+	histograms := []Histogram{}
+	for _, r := range results {
+		histograms = append(histograms, r.Histogram(name))
+	}
+	return histograms
+}
+
+// Gets the named total from every result
+func TotalsNamed(results []*SeedResult, name string) []float64 {
+	totals := []float64{}
+	for _, r := range results {
+		totals = append(totals, r.Totals[name])
+	}
+	return totals
+}
+
+// Prints a table of bucket statistics with the given column names
+func PrintHistogramStats(w io.Writer, bucketName, countName string, stats []BucketStats) {
+	fmt.Fprintln(w, bucketName, countName+"_mean", countName+"_stddev", countName+"_ci95_low", countName+"_ci95_high")
+	for _, b := range stats {
+		fmt.Fprintf(w, "%d %f %f %f %f\n", b.Bucket, b.Mean, b.StdDev, b.CILow, b.CIHigh)
+	}
+}
+
+// Prints the mean and 95% confidence interval for a named total
+func PrintTotalStats(w io.Writer, results []*SeedResult, name string) {
+	s := Summarise(TotalsNamed(results, name))
+	fmt.Fprintf(w, "%f %s (stddev %f, ci95 %f to %f)\n", s.Mean, name, s.StdDev, s.CILow, s.CIHigh)
+}
+
+// Prints the warning totals found in any result
+func PrintWarningStats(w io.Writer, results []*SeedResult) {
+	for _, name := range WarningNames(results) {
+		PrintTotalStats(w, results, name)
+	}
+}
+
+// two-tailed 95% critical values of Student's t distribution
+var tTable95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func tCritical95(degreesOfFreedom int) float64 {
+	if degreesOfFreedom < 1 {
+		return 0
+	}
+	if degreesOfFreedom <= len(tTable95) {
+		return tTable95[degreesOfFreedom-1]
+	}
+	// normal approximation for large samples
+	return 1.96
+}
@@ -0,0 +1,59 @@
+package safenet
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestSummarise(t *testing.T) {
+	// mean 2, sample stddev 1, t = 4.303 for 2 degrees of freedom
+	s := Summarise([]float64{1, 2, 3})
+	halfWidth := 4.303 / math.Sqrt(3)
+	if !near(s.Mean, 2) || !near(s.StdDev, 1) || !near(s.CILow, 2-halfWidth) || !near(s.CIHigh, 2+halfWidth) {
+		t.Errorf("Summary of 1, 2, 3 is %+v", s)
+	}
+	// one seed has no spread
+	s = Summarise([]float64{5})
+	if s != (Summary{5, 0, 5, 5}) {
+		t.Errorf("Summary of one seed is %+v", s)
+	}
+	// zero variance gives an empty interval
+	s = Summarise([]float64{3, 3, 3, 3})
+	if s != (Summary{3, 0, 3, 3}) {
+		t.Errorf("Summary of equal values is %+v", s)
+	}
+	if Summarise([]float64{}) != (Summary{}) {
+		t.Error("Expected an empty summary for no seeds")
+	}
+}
+
+func TestSummariseHistograms(t *testing.T) {
+	a := Histogram{1: 2, 2: 4}
+	b := Histogram{1: 4}
+	stats := SummariseHistograms([]Histogram{a, b})
+	if len(stats) != 2 || stats[0].Bucket != 1 || stats[1].Bucket != 2 {
+		t.Fatalf("Buckets are %+v", stats)
+	}
+	// bucket 1 is 2 and 4, bucket 2 is 4 and a missing 0
+	if !near(stats[0].Mean, 3) || !near(stats[0].StdDev, math.Sqrt2) {
+		t.Errorf("Bucket 1 is %+v", stats[0])
+	}
+	// t = 12.706 for 1 degree of freedom, times a stddev of 2√2 over √2 seeds
+	halfWidth := 12.706 * 2
+	if !near(stats[1].Mean, 2) || !near(stats[1].StdDev, 2*math.Sqrt2) || !near(stats[1].CIHigh, 2+halfWidth) {
+		t.Errorf("Bucket 2 is %+v", stats[1])
+	}
+}
+
+func TestTCritical95(t *testing.T) {
+	cases := map[int]float64{0: 0, 1: 12.706, 7: 2.365, 30: 2.042, 31: 1.96, 1000: 1.96}
+	for df, expected := range cases {
+		if tCritical95(df) != expected {
+			t.Errorf("t for %d degrees of freedom is %f, expected %f", df, tCritical95(df), expected)
+		}
+	}
+}
@@ -1,23 +1,14 @@
 package safenet
 
 import (
-	"fmt"
-	"math"
 	"math/rand"
 	"sort"
 )
 
-const GroupSize = 8
-const SplitBuffer = 3
-const QuorumNumerator = 1
-const QuorumDenominator = 2
-const SplitSize = GroupSize + SplitBuffer
 const MaxSafecoins = 4294967296 // 2^32
 
-var prng = rand.New(rand.NewSource(0))
-
 type Network struct {
-	Sections          map[string]*Section
+	sections          prefixTrie
 	Clients           []Client
 	TotalMerges       int
 	TotalSplits       int
@@ -25,282 +16,320 @@ type Network struct {
 	TotalDepartures   int
 	TotalRelocations  int
 	NeighbourhoodHops []int
+	VaultSampling     VaultSampling
+	EventHashing      EventHashing
+	Params            Params
+	// describes how the network was built. It is saved in snapshots so a
+	// loaded network can be checked against the config that wants it.
+	Label string
+	// estimates the messages sent for each membership event
+	CostModel CostModel
+	// check invariants after every membership event and panic with an
+	// InvariantError on the first violation. This is slow.
+	Debug bool
+	// every live vault, kept up to date as vaults join and depart
+	vaults vaultRegistry
+	// aggregates kept up to date as clients change so they can be queried
+	// after every event
+	totalSafecoins int32
+	// each network owns its random sources so several networks can be
+	// simulated in one process without affecting each other
+	rng  randomStreams
+	seed int64
+	// membership events are numbered and linked to the event that caused
+	// them, then passed to observers
+	eventSeq    int
+	eventCauses []int
+	observers   []Observer
+	// the id of each observer in observers
+	observerIds    []ObserverId
+	lastObserverId ObserverId
+	// elder changes waiting for the event causing them to complete
+	pendingElderChanges []*MembershipEvent
+	trace               *EventTrace
+	traceId             ObserverId
+	// counts of errors the network recovered from, keyed by message
+	warnings map[string]int
+	// chooses which vault is relocated after each event, nil uses the event
+	// hash and vault age
+	RelocationTrigger RelocationTrigger
+	// chooses where relocated vaults go, nil uses the smallest neighbour
+	Relocation RelocationStrategy
+	// decides which joining vaults are admitted, nil admits every vault
+	Admission AdmissionPolicy
+	// join queue metrics, rejections count each vault once and waits are in
+	// steps
+	TotalRejections    int
+	MaxJoinQueueLength int
+	JoinWaits          []int
+	steps              int
+	joinQueue          []queuedJoin
+}
+
+func NewNetwork(p Params) *Network {
+	return NewNetworkFromSeed(0, p)
 }
 
-func NewNetwork() Network {
-	return Network{
-		Sections:          map[string]*Section{},
+func NewNetworkFromSeed(seed int64, p Params) *Network {
+	return &Network{
+		sections:          newPrefixTrie(),
 		Clients:           []Client{},
 		NeighbourhoodHops: []int{},
+		Params:            p,
+		CostModel:         DefaultCostModel(),
+		rng:               newRandomStreams(seed),
+		seed:              seed,
 	}
 }
 
-func NewNetworkFromSeed(seed int64) Network {
-	prng = rand.New(rand.NewSource(seed))
-	return NewNetwork()
+// The seed the random streams started from
+func (n *Network) Seed() int64 {
+	return n.seed
 }
 
+// Returns true if the admission policy rejected the vault, in which case it
+// waits in the join queue and joins once a later step admits it.
 func (n *Network) AddVault(v *Vault) bool {
+	n.beginStep()
+	if !n.admit(v) {
+		n.enqueue(v)
+		return true
+	}
+	n.joinVault(v)
+	return false
+}
+
+func (n *Network) joinVault(v *Vault) {
 	// track stats
 	n.TotalJoins = n.TotalJoins + 1
+	e := n.beginEvent(JoinEvent)
+	e.setVault(v)
+	defer n.endEvent(e)
 	// get prefix for vault
-	prefix := n.getPrefixForXorname(v.Name)
-	section, exists := n.Sections[prefix.Key]
+	prefix, err := n.getPrefixForXorname(v.Name)
+	n.warn(err)
+	section := n.sections.get(prefix)
 	// get the section for this prefix
-	if !exists {
+	if section == nil {
 		blankPrefix := NewBlankPrefix()
-		ne := newSection(blankPrefix, []*Vault{})
+		ne := newSection(n, JoinEvent, blankPrefix, []*Vault{})
 		if ne != nil {
 			for _, section = range ne.NewSections {
-				n.Sections[section.Prefix.Key] = section
+				n.sections.insert(section)
 			}
 		}
 	}
+	e.addBefore(section)
 	// add the vault to the section
-	ne, disallowed := section.addVault(v)
+	ne := section.addVault(v)
+	n.vaults.add(v)
+	e.charge(n.sectionChangeCost(section))
+	e.charge(n.CostModel.chunks(len(v.Chunks)))
 	// if there was a split
 	if ne != nil && len(ne.NewSections) > 0 {
 		n.TotalSplits = n.TotalSplits + 1
+		se := n.beginEvent(SplitEvent)
+		se.addBefore(section)
+		// remove old section
+		n.sections.remove(section.Prefix)
 		// add new sections
 		for _, s := range ne.NewSections {
-			n.Sections[s.Prefix.Key] = s
+			n.sections.insert(s)
+			se.addAfter(s)
+			e.addAfter(s)
 		}
-		// remove old section
-		delete(n.Sections, section.Prefix.Key)
+		for _, s := range ne.NewSections {
+			se.charge(n.sectionChangeCost(s))
+		}
+		n.departDropped(ne)
+		n.endEvent(se)
+	} else {
+		e.addAfter(section)
 	}
 	// relocate vault if there is one to relocate
 	if ne != nil && ne.VaultToRelocate != nil {
 		n.relocateVault(ne)
 	}
-	return disallowed
 }
 
-func (n *Network) RemoveVault(v *Vault) {
-	n.TotalDepartures = n.TotalDepartures + 1
-	section, exists := n.Sections[v.Prefix.Key]
-	if !exists {
-		fmt.Println("Warning: No section for removeVault")
-		return
+// Returns ErrNoSectionForVault if the vault is not in the network, in which
+// case nothing is changed.
+func (n *Network) RemoveVault(v *Vault) error {
+	n.beginStep()
+	section := n.sections.get(v.Prefix)
+	if section == nil || !section.hasVault(v) {
+		n.warn(ErrNoSectionForVault)
+		return ErrNoSectionForVault
 	}
+	n.TotalDepartures = n.TotalDepartures + 1
+	e := n.beginEvent(DepartureEvent)
+	e.setVault(v)
+	defer n.endEvent(e)
+	e.addBefore(section)
 	// remove the vault from the section
 	ne := section.removeVault(v)
+	n.vaults.remove(v)
+	e.addAfter(section)
+	e.charge(n.sectionChangeCost(section))
 	// merge if needed
 	if section.shouldMerge() && n.HasMoreThanOneSection() {
 		n.TotalMerges = n.TotalMerges + 1
+		me := n.beginEvent(MergeEvent)
+		me.addBefore(section)
 		parentPrefix := section.Prefix.parent()
-		// get sibling prefix
-		siblingPrefix := v.Prefix.sibling()
-		// get sibling vaults
+		// get sibling vaults, which is either the sibling section or all
+		// the sections below the sibling prefix
 		parentVaults := section.Vaults
-		_, exists := n.Sections[siblingPrefix.Key]
-		if exists {
-			// merge sibling
-			siblingVaults := n.Sections[siblingPrefix.Key].Vaults
-			parentVaults = append(parentVaults, siblingVaults...)
-			delete(n.Sections, siblingPrefix.Key)
-		} else {
-			// get child vaults
-			childPrefixes := n.getChildPrefixes(siblingPrefix)
-			for _, childPrefix := range childPrefixes {
-				// merge child vault
-				childVaults := n.Sections[childPrefix.Key].Vaults
-				parentVaults = append(parentVaults, childVaults...)
-				delete(n.Sections, childPrefix.Key)
-			}
+		merging := []*Section{section}
+		for _, sibling := range n.sections.siblings(section.Prefix) {
+			me.addBefore(sibling)
+			parentVaults = append(parentVaults, sibling.Vaults...)
+			merging = append(merging, sibling)
+			n.sections.remove(sibling.Prefix)
 		}
+		me.charge(n.mergeChunkCost(merging))
 		// remove the merged section
-		delete(n.Sections, section.Prefix.Key)
+		n.sections.remove(section.Prefix)
 		// create the new section
 		// TODO set storage consumption after merge
-		ne := newSection(parentPrefix, parentVaults)
+		ne := newSection(n, MergeEvent, parentPrefix, parentVaults)
 		if ne != nil {
 			for _, s := range ne.NewSections {
-				n.Sections[s.Prefix.Key] = s
+				n.sections.insert(s)
+				me.addAfter(s)
 			}
+			for _, s := range ne.NewSections {
+				me.charge(n.sectionChangeCost(s))
+			}
+			n.departDropped(ne)
 		}
+		n.endEvent(me)
 	} else if ne != nil && ne.VaultToRelocate != nil {
 		// if there is no merge but there is a vault to relocate,
 		// relocate the vault
 		n.relocateVault(ne)
 	}
+	return nil
+}
+
+// Vaults dropped by a split are no longer in any section, so they are
+// removed from the registry and recorded as departures caused by the split.
+func (n *Network) departDropped(ne *NetworkEvent) {
+	for _, v := range ne.DroppedVaults {
+		n.TotalDepartures = n.TotalDepartures + 1
+		e := n.beginEvent(DepartureEvent)
+		e.setVault(v)
+		n.vaults.remove(v)
+		n.endEvent(e)
+	}
 }
 
 func (n *Network) relocateVault(ne *NetworkEvent) {
 	// track stats for relocations
 	n.TotalRelocations = n.TotalRelocations + 1
-	// find the neighbour with shortest prefix or fewest vaults
-	// default to the existing section, useful for zero-length prefix
-	smallestNeighbour := n.Sections[ne.VaultToRelocate.Prefix.Key]
-	minNeighbourPrefix := math.MaxUint32
-	minNeighbourVaults := math.MaxUint32
-	// get all neighbours
-	for i := 0; i < len(ne.VaultToRelocate.Prefix.bits); i++ {
-		// clone the prefix but flip the ith bit of the prefix
-		neighbourPrefix := NewBlankPrefix()
-		for j := 0; j < len(ne.VaultToRelocate.Prefix.bits); j++ {
-			isZero := !ne.VaultToRelocate.Prefix.bits[j]
-			if j == i {
-				isZero = !isZero
-			}
-			if isZero {
-				neighbourPrefix = neighbourPrefix.extendLeft()
-			} else {
-				neighbourPrefix = neighbourPrefix.extendRight()
-			}
-		}
-		// get neighbouring prefixes from the network for this prefix
-		// and repeat until we arrive at the 'best' neighbour prefix
-		prevNeighbourPrefix := NewBlankPrefix()
-		for !neighbourPrefix.Equals(prevNeighbourPrefix) {
-			// track previous neighbour prefix
-			prevNeighbourPrefix = neighbourPrefix
-			// get potential new neighbour prefixes
-			neighbourPrefixes := n.getMatchingPrefixes(neighbourPrefix)
-			// check if these neighbours contain the 'best' neighbour
-			// prioritise sections with shorter prefixes and having less nodes to balance the network
-			for _, p := range neighbourPrefixes {
-				s := n.Sections[p.Key]
-				if len(p.bits) < minNeighbourPrefix {
-					// prefer shorter prefixes
-					neighbourPrefix = p
-					minNeighbourPrefix = len(p.bits)
-					smallestNeighbour = s
-				} else if len(p.bits) == minNeighbourPrefix {
-					// prefer less vaults if prefix length is same
-					if len(s.Vaults) < minNeighbourVaults {
-						neighbourPrefix = p
-						minNeighbourVaults = len(s.Vaults)
-						smallestNeighbour = s
-					} else if len(s.Vaults) == minNeighbourVaults {
-						// TODO tiebreaker for equal sized neighbours
-						// see https://forum.safedev.org/t/data-chains-deeper-dive/1209
-						// If all neighbours have the same number of peers we relocate
-						// to the section closest to the H above (that is not us)
-					}
-				}
-			}
-		}
+	e := n.beginEvent(RelocationEvent)
+	e.setVault(ne.VaultToRelocate)
+	defer n.endEvent(e)
+	oldName := ne.VaultToRelocate.Name
+	strategy := n.Relocation
+	if strategy == nil {
+		strategy = SmallestNeighbourRelocation{}
 	}
+	target := strategy.Target(n, ne.VaultToRelocate, ne)
 	// track neighbourhood hops by comparing how many bits differ
 	// between the new and the old prefix.
-	neighbourhoodHops := 0
-	prefixLength := len(smallestNeighbour.Prefix.Key)
-	if len(ne.VaultToRelocate.Prefix.Key) < prefixLength {
-		prefixLength = len(ne.VaultToRelocate.Prefix.Key)
-	}
-	for i := 0; i < prefixLength; i++ {
-		newBit := smallestNeighbour.Prefix.Key[i]
-		oldBit := ne.VaultToRelocate.Prefix.Key[i]
-		if newBit != oldBit {
-			neighbourhoodHops = neighbourhoodHops + 1
-		}
-	}
+	neighbourhoodHops := target.Prefix.DifferingBits(ne.VaultToRelocate.Prefix)
 	n.NeighbourhoodHops = append(n.NeighbourhoodHops, neighbourhoodHops)
+	source := n.sections.get(ne.VaultToRelocate.Prefix)
+	e.addBefore(source)
+	e.addBefore(target)
+	e.charge(n.relocationCost(source, target))
 	// remove vault from current section (includes merge if needed)
 	n.RemoveVault(ne.VaultToRelocate)
-	// adjust vault name to match the neighbour section prefix
-	ne.VaultToRelocate.renameWithPrefix(smallestNeighbour.Prefix)
+	// adjust vault name to match the target section prefix
+	ne.VaultToRelocate.renameWithPrefix(n, target.Prefix)
 	// age the relocated vault
 	ne.VaultToRelocate.IncrementAge()
-	// relocate the vault to the smallest neighbour (includes split if needed)
-	disallowed := n.AddVault(ne.VaultToRelocate)
-	if disallowed {
-		fmt.Println("Warning: disallowed relocated vault")
-	}
+	ne.VaultToRelocate.ChurnCount = 0
+	// relocate the vault to the target (includes split if needed),
+	// which is not subject to the admission policy
+	n.joinVault(ne.VaultToRelocate)
+	// the sections now holding the vault and covering its old name
+	e.addAfter(n.sections.longestMatch(ne.VaultToRelocate.Name))
+	e.addAfter(n.sections.longestMatch(oldName))
 }
 
 func (n *Network) GetRandomSection() *Section {
-	x := NewXorName()
-	p := n.getPrefixForXorname(x)
-	s, _ := n.Sections[p.Key]
-	return s
+	return n.randomSection(n.rng.churn)
+}
+
+func (n *Network) randomSection(prng *rand.Rand) *Section {
+	x := newXorName(prng)
+	return n.sections.longestMatch(x)
 }
 
 // Needs to be deterministic but also random.
-// Iterating over keys of a map is not deterministic
+// The vault is chosen according to n.VaultSampling.
 func (n *Network) GetRandomVault() *Vault {
-	s := n.GetRandomSection()
-	return s.GetRandomVault()
+	if n.VaultSampling == SectionWeightedVaultSampling {
+		s := n.GetRandomSection()
+		if s == nil {
+			return nil
+		}
+		v, err := s.GetRandomVault()
+		n.warn(err)
+		return v
+	}
+	return n.vaults.random(n.rng.churn)
 }
 
 // Returns the parent, prefix, or children that matches this prefix on the network
 func (n *Network) getMatchingPrefixes(prefix Prefix) []Prefix {
-	prefixes := []Prefix{}
-	testPrefix := NewBlankPrefix()
-	// find possible parents
-	_, exists := n.Sections[testPrefix.Key]
-	if exists {
-		prefixes = append(prefixes, testPrefix)
-	}
-	for i := 0; i < len(prefix.bits); i++ {
-		if !prefix.bits[i] {
-			testPrefix = testPrefix.extendLeft()
-		} else {
-			testPrefix = testPrefix.extendRight()
-		}
-		_, exists := n.Sections[testPrefix.Key]
-		if exists {
-			prefixes = append(prefixes, testPrefix)
-			// TODO can probably break here?
+	return sectionPrefixes(n.sections.matching(prefix))
+}
+
+// Returns the blank prefix when there are no sections yet, which is only an
+// error once the network has vaults.
+func (n *Network) getPrefixForXorname(x XorName) (Prefix, error) {
+	s := n.sections.longestMatch(x)
+	if s == nil {
+		if n.HasMoreThanOneVault() {
+			return NewBlankPrefix(), ErrNoSectionForName
 		}
+		return NewBlankPrefix(), nil
 	}
-	// get child prefixes if no parent found
-	if len(prefixes) == 0 {
-		prefixes = n.getChildPrefixes(prefix)
-	}
-	return prefixes
+	return s.Prefix, nil
 }
 
-func (n *Network) getChildPrefixes(prefix Prefix) []Prefix {
+func sectionPrefixes(sections []*Section) []Prefix {
 	prefixes := []Prefix{}
-	leftPrefix := prefix.extendLeft()
-	rightPrefix := prefix.extendRight()
-	_, leftExists := n.Sections[leftPrefix.Key]
-	_, rightExists := n.Sections[rightPrefix.Key]
-	if leftExists && rightExists {
-		prefixes = append(prefixes, leftPrefix, rightPrefix)
-	} else if leftExists {
-		prefixes = append(prefixes, leftPrefix)
-		prefixes = append(prefixes, n.getChildPrefixes(rightPrefix)...)
-	} else if rightExists {
-		prefixes = append(prefixes, rightPrefix)
-		prefixes = append(prefixes, n.getChildPrefixes(leftPrefix)...)
-	} else if len(prefix.bits) < 256 {
-		prefixes = append(prefixes, n.getChildPrefixes(leftPrefix)...)
-		prefixes = append(prefixes, n.getChildPrefixes(rightPrefix)...)
-	} else {
-		fmt.Println("Warning: No children exist for prefix")
+	for _, s := range sections {
+		prefixes = append(prefixes, s.Prefix)
 	}
 	return prefixes
 }
 
-func (n *Network) getPrefixForXorname(x XorName) Prefix {
-	prefix := NewBlankPrefix()
-	_, exists := n.Sections[prefix.Key]
-	for !exists && len(prefix.bits) < len(x.bits) {
-		// get the next bit of the xorname prefix
-		bit := x.bits[len(prefix.bits)]
-		// extend the prefix depending on the bit of the xorname
-		if bit == false {
-			prefix = prefix.extendLeft()
-		} else {
-			prefix = prefix.extendRight()
-		}
-		_, exists = n.Sections[prefix.Key]
-	}
-	if !exists && n.HasMoreThanOneVault() {
-		fmt.Println("Warning: No prefix for xorname")
-		return NewBlankPrefix()
-	}
-	return prefix
+// Returns the section with exactly this prefix, or nil if there is none.
+func (n *Network) GetSection(p Prefix) *Section {
+	return n.sections.get(p)
+}
+
+// Returns the section responsible for this name.
+func (n *Network) GetSectionForXorname(x XorName) *Section {
+	return n.sections.longestMatch(x)
+}
+
+// Returns every section in the network ordered by prefix.
+func (n *Network) Sections() []*Section {
+	return n.sections.all()
 }
 
 func (n *Network) ReportAges() (map[int]int, []int) {
 	ages := map[int]int{}
 	ageKeys := []int{}
 	count := 0
-	for p := range n.Sections {
-		for _, v := range n.Sections[p].Vaults {
+	for _, s := range n.sections.all() {
+		for _, v := range s.Vaults {
 			count = count + 1
 			_, exists := ages[v.Age]
 			if !exists {
@@ -315,59 +344,29 @@ func (n *Network) ReportAges() (map[int]int, []int) {
 }
 
 func (n *Network) TotalVaults() int {
-	vaults := 0
-	for p := range n.Sections {
-		vaults = vaults + len(n.Sections[p].Vaults)
-	}
-	return vaults
+	return n.vaults.len()
 }
 
 func (n *Network) TotalSections() int {
-	sections := 0
-	for range n.Sections {
-		sections = sections + 1
-	}
-	return sections
+	return n.sections.len()
 }
 
 func (n *Network) HasMoreThanOneVault() bool {
-	vaults := 0
-	for p := range n.Sections {
-		vaults = vaults + len(n.Sections[p].Vaults)
-		if vaults > 1 {
-			return true
-		}
-	}
-	return false
+	return n.vaults.len() > 1
 }
 
 func (n *Network) HasMoreThanOneSection() bool {
-	sections := 0
-	for range n.Sections {
-		sections = sections + 1
-		if sections > 1 {
-			return true
-		}
-	}
-	return false
+	return n.sections.len() > 1
 }
 
 func (n *Network) HasOneSection() bool {
-	sections := 0
-	for range n.Sections {
-		sections = sections + 1
-		if sections > 1 {
-			return false
-		}
-	}
-	return sections == 1
+	return n.sections.len() == 1
 }
 
 func (n *Network) DoRandomPut(u Uploader, o Operator) bool {
 	didUpload := false
-	chunkName := NewXorName()
-	prefix := n.getPrefixForXorname(chunkName)
-	section := n.Sections[prefix.Key]
+	chunkName := newXorName(n.rng.clients)
+	section := n.sections.longestMatch(chunkName)
 	// get the cost to upload this chunk
 	cost := section.SafecoinPerMb()
 	// check the uploader has enough putbalance
@@ -379,6 +378,7 @@ func (n *Network) DoRandomPut(u Uploader, o Operator) bool {
 	o.AllocatePuts(-1 * cost)
 	// store the chunk on the network
 	section.PutChunk(chunkName, u)
+	n.notifyPut(chunkName, section, u)
 	didUpload = true
 	return didUpload
 }
@@ -387,37 +387,43 @@ func (n *Network) DoRandomGet() {
 	// get triggers opportunity to farm.
 	// check the opportunity passes the farm rate test.
 	// see https://github.com/maidsafe/rfcs/blob/master/text/0012-safecoin-implementation/0012-safecoin-implementation.md#farm-request-calculation
-	chunkName := NewXorName()
-	prefix := n.getPrefixForXorname(chunkName)
-	section := n.Sections[prefix.Key]
+	chunkName := newXorName(n.rng.clients)
+	section := n.sections.longestMatch(chunkName)
+	n.notifyGet(chunkName, section)
 	farmDivisor := section.FarmDivisor()
 	if farmDivisor > 0 {
-		chunkHash := NewXorName() // simulated hash of PmidHolderName + chunkHame
-		testPasses := bigIntModInt64IsZero(chunkHash.bigint, farmDivisor)
+		chunkHash := newXorName(n.rng.farming) // simulated hash of PmidHolderName + chunkHame
+		testPasses := chunkHash.ModIsZero(farmDivisor)
 		if !testPasses {
 			return
 		}
 	}
 	// try creating the coin if it doesn't exist yet
 	// do it statistically based on percent of total safecoin issued
-	exists := prng.Float64() < float64(n.TotalSafecoins())/float64(MaxSafecoins)
+	exists := n.rng.farming.Float64() < float64(n.TotalSafecoins())/float64(MaxSafecoins)
 	if !exists {
-		section.AllocateSafecoin()
+		n.warn(section.AllocateSafecoin())
 	}
 }
 
+// Safecoins held by clients. Coins held by a client when it is added to the
+// network are counted, after which the total is updated as the network
+// allocates coins to vaults and exchanges coins for puts.
 func (n *Network) TotalSafecoins() int32 {
-	var total int32
-	for _, c := range n.Clients {
-		total = total + c.TotalSafecoins()
-	}
-	return total
+	return n.totalSafecoins
+}
+
+// Changes the coins of an operator whose client is in the network, keeping
+// the total up to date. Coins of added clients must only change here.
+func (n *Network) allocateSafecoins(o Operator, coins int32) {
+	o.AllocateSafecoins(coins)
+	n.totalSafecoins = n.totalSafecoins + coins
 }
 
 func (n *Network) AvgSafecoinPerMb() float64 {
 	var sum float64
 	var sections float64
-	for _, s := range n.Sections {
+	for _, s := range n.sections.all() {
 		sum = s.SafecoinPerMb()
 		sections = sections + 1
 	}
@@ -427,7 +433,7 @@ func (n *Network) AvgSafecoinPerMb() float64 {
 func (n *Network) AvgFarmDivisor() float64 {
 	var sum float64
 	var sections float64
-	for _, s := range n.Sections {
+	for _, s := range n.sections.all() {
 		sum = sum + float64(s.FarmDivisor())
 		sections = sections + 1
 	}
@@ -436,6 +442,7 @@ func (n *Network) AvgFarmDivisor() float64 {
 
 func (n *Network) AddClient(c Client) {
 	n.Clients = append(n.Clients, c)
+	n.totalSafecoins = n.totalSafecoins + c.TotalSafecoins()
 }
 
 func (n *Network) TotalClients() int {
@@ -445,12 +452,12 @@ func (n *Network) TotalClients() int {
 func (n *Network) BuyPuts(coins int32, o Operator) {
 	// sell the coin to a random section
 	// TODO confirm this is how it would work?!
-	section := n.GetRandomSection()
+	section := n.randomSection(n.rng.clients)
 	cost := section.SafecoinPerMb()
 	mbPerCoin := 1 / cost
 	puts := mbPerCoin * float64(coins)
 	// deduct coins from operator
-	o.AllocateSafecoins(-1 * coins)
+	n.allocateSafecoins(o, -1*coins)
 	// credit put balance to operator
 	o.AllocatePuts(puts)
 }
@@ -1,33 +1,93 @@
 package safenet
 
 import (
-	"math/big"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// How the hash H of a NetworkEvent is made.
+type EventHashing int
+
+const (
+	// H is drawn from the events random stream
+	RandomEventHashing EventHashing = iota
+	// H is the sha256 of the event type, section prefix, the name of the
+	// joining or departing vault and the sequence number of the membership
+	// event, as in the real design where H is the hash of the agreed event.
+	// An adversary choosing when to join and with which name can predict and
+	// steer H.
+	ContentEventHashing
 )
 
 type NetworkEvent struct {
-	hash            *big.Int
+	hash            XorName
 	NewSections     []*Section
 	VaultToRelocate *Vault
+	// vaults a split could not place in either half, which depart
+	DroppedVaults []*Vault
 }
 
-const networkeventHashBits = 256
-const networkeventHashBytes = networkeventHashBits / 8
-
-var largestHashValue = []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
-
-func NewNetworkEvent() *NetworkEvent {
+func NewNetworkEvent(n *Network) *NetworkEvent {
 	ne := NetworkEvent{}
-	// create a hash from prng
-	b := make([]byte, networkeventHashBytes)
-	prng.Read(b)
-	ne.hash = big.NewInt(0)
-	ne.hash.SetBytes(b)
+	// create a hash from the network event prng
+	for i := 0; i < xornameBytes; i = i + 8 {
+		binary.BigEndian.PutUint64(ne.hash[i:], n.rng.events.Uint64())
+	}
 	return &ne
 }
 
-// calculates x = b.hash % divisor and returns x == 0
-func (ne NetworkEvent) HashModIsZero(divisor *big.Int) bool {
-	x := big.NewInt(0)
-	x.Mod(ne.hash, divisor)
-	return x.Cmp(big.NewInt(0)) == 0
+// Creates the event for a section change, hashed according to
+// n.EventHashing. v is the joining or departing vault or nil.
+func (n *Network) newNetworkEvent(t EventType, p Prefix, v *Vault) *NetworkEvent {
+	if n.EventHashing != ContentEventHashing {
+		return NewNetworkEvent(n)
+	}
+	var name XorName
+	if v != nil {
+		name = v.Name
+	}
+	return &NetworkEvent{
+		hash: EventContentHash(t, p, name, n.currentEventSeq()),
+	}
+}
+
+// The sequence number of the membership event in progress. Elder changes
+// recorded during the event take later numbers but do not change it.
+func (n *Network) currentEventSeq() int {
+	if len(n.eventCauses) == 0 {
+		return n.eventSeq
+	}
+	return n.eventCauses[len(n.eventCauses)-1]
+}
+
+// The hash of a section event used by ContentEventHashing, where name is
+// zero for splits and merges and seq is the sequence number of the
+// membership event in progress.
+func EventContentHash(t EventType, p Prefix, name XorName, seq int) XorName {
+	b := []byte(t)
+	b = append(b, 0)
+	b = append(b, byte(p.length>>8), byte(p.length))
+	b = append(b, p.bits[:]...)
+	b = append(b, name[:]...)
+	var seqBytes [8]byte
+	binary.BigEndian.PutUint64(seqBytes[:], uint64(seq))
+	b = append(b, seqBytes[:]...)
+	return sha256.Sum256(b)
+}
+
+// Predicts the hash of the event when v joins the network with
+// ContentEventHashing, assuming v is admitted and no queued vault joins
+// first. A grinding adversary can try names until the hash suits it.
+func (n *Network) PredictJoinHash(v *Vault) XorName {
+	p, _ := n.getPrefixForXorname(v.Name)
+	return EventContentHash(JoinEvent, p, v.Name, n.eventSeq+1)
+}
+
+func (ne NetworkEvent) Hash() XorName {
+	return ne.hash
+}
+
+// calculates x = ne.hash % 2^exponent and returns x == 0
+func (ne NetworkEvent) HashModPow2IsZero(exponent int) bool {
+	return ne.hash.TrailingZeros() >= exponent
 }
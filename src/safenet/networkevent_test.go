@@ -0,0 +1,69 @@
+package safenet
+
+import (
+	"testing"
+)
+
+// records the hash of every section event
+type hashRecorder struct {
+	hashes []XorName
+}
+
+func (r *hashRecorder) VaultToRelocate(s *Section, ne *NetworkEvent) *Vault {
+	r.hashes = append(r.hashes, ne.Hash())
+	return nil
+}
+
+func TestPredictJoinHash(t *testing.T) {
+	n := buildTestNetwork(1, 500)
+	n.EventHashing = ContentEventHashing
+	r := &hashRecorder{}
+	n.RelocationTrigger = r
+	v := NewVault(n)
+	predicted := n.PredictJoinHash(v)
+	n.AddVault(v)
+	if len(r.hashes) != 1 || r.hashes[0] != predicted {
+		t.Errorf("Predicted %s but join hashes were %v", predicted.Hex(), r.hashes)
+	}
+}
+
+func TestContentHashesDoNotUseRandomStream(t *testing.T) {
+	// the first draw of a fresh stream
+	first := NewNetworkFromSeed(1, DefaultParams()).rng.events.Uint64()
+	n := NewNetworkFromSeed(1, DefaultParams())
+	n.EventHashing = ContentEventHashing
+	for i := 0; i < 100; i++ {
+		n.AddVault(NewVault(n))
+	}
+	if n.rng.events.Uint64() != first {
+		t.Error("Content hashing drew from the events stream")
+	}
+	a := EventContentHash(JoinEvent, NewBlankPrefix(), XorName{1}, 5)
+	if a == EventContentHash(DepartureEvent, NewBlankPrefix(), XorName{1}, 5) {
+		t.Error("Event type does not change the hash")
+	}
+	if a == EventContentHash(JoinEvent, NewBlankPrefix(), XorName{1}, 6) {
+		t.Error("Sequence number does not change the hash")
+	}
+}
+
+func TestPredictJoinHashPromotingElder(t *testing.T) {
+	// every vault is an elder while the network is smaller than a group
+	n := NewNetworkFromSeed(1, DefaultParams())
+	n.EventHashing = ContentEventHashing
+	r := &hashRecorder{}
+	n.RelocationTrigger = r
+	for i := 0; i < 3; i++ {
+		n.AddVault(NewVault(n))
+	}
+	r.hashes = nil
+	v := NewVault(n)
+	predicted := n.PredictJoinHash(v)
+	n.AddVault(v)
+	if !v.elder {
+		t.Fatal("Joining vault was not promoted")
+	}
+	if len(r.hashes) != 1 || r.hashes[0] != predicted {
+		t.Errorf("Predicted %s but join hashes were %v", predicted.Hex(), r.hashes)
+	}
+}
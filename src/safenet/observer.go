@@ -0,0 +1,96 @@
+package safenet
+
+// Observers are notified of everything that happens on a network so that
+// statistics can be collected without changing Network.
+//
+// Membership callbacks receive the completed event, see MembershipEvent.
+// Since events are delivered when they complete, the events caused by an
+// event are always delivered before it.
+type Observer interface {
+	OnJoin(e *MembershipEvent)
+	OnDeparture(e *MembershipEvent)
+	OnSplit(e *MembershipEvent)
+	OnMerge(e *MembershipEvent)
+	OnRelocate(e *MembershipEvent)
+	OnElderChange(e *MembershipEvent)
+	// a chunk was stored by the section
+	OnPut(chunk XorName, s *Section, u Uploader)
+	// a chunk was fetched from the section
+	OnGet(chunk XorName, s *Section)
+	// a safecoin was farmed by the vault
+	OnFarm(v *Vault, s *Section)
+}
+
+// Implements every Observer method as a no-op so observers can embed it and
+// only implement the callbacks they need.
+type BaseObserver struct{}
+
+func (b BaseObserver) OnJoin(e *MembershipEvent)                   {}
+func (b BaseObserver) OnDeparture(e *MembershipEvent)              {}
+func (b BaseObserver) OnSplit(e *MembershipEvent)                  {}
+func (b BaseObserver) OnMerge(e *MembershipEvent)                  {}
+func (b BaseObserver) OnRelocate(e *MembershipEvent)               {}
+func (b BaseObserver) OnElderChange(e *MembershipEvent)            {}
+func (b BaseObserver) OnPut(chunk XorName, s *Section, u Uploader) {}
+func (b BaseObserver) OnGet(chunk XorName, s *Section)             {}
+func (b BaseObserver) OnFarm(v *Vault, s *Section)                 {}
+
+// Identifies an observer added to a network. Observers are removed by id
+// since observers of any type can be added, including types which cannot be
+// compared.
+type ObserverId int
+
+// Observers are called in the order they were added.
+func (n *Network) AddObserver(o Observer) ObserverId {
+	n.lastObserverId = n.lastObserverId + 1
+	n.observers = append(n.observers, o)
+	n.observerIds = append(n.observerIds, n.lastObserverId)
+	return n.lastObserverId
+}
+
+func (n *Network) RemoveObserver(id ObserverId) {
+	for i, existing := range n.observerIds {
+		if existing == id {
+			n.observers = append(n.observers[:i], n.observers[i+1:]...)
+			n.observerIds = append(n.observerIds[:i], n.observerIds[i+1:]...)
+			return
+		}
+	}
+}
+
+func (n *Network) notifyMembershipEvent(e *MembershipEvent) {
+	for _, o := range n.observers {
+		switch e.Type {
+		case JoinEvent:
+			o.OnJoin(e)
+		case DepartureEvent:
+			o.OnDeparture(e)
+		case SplitEvent:
+			o.OnSplit(e)
+		case MergeEvent:
+			o.OnMerge(e)
+		case RelocationEvent:
+			o.OnRelocate(e)
+		case ElderChangeEvent:
+			o.OnElderChange(e)
+		}
+	}
+}
+
+func (n *Network) notifyPut(chunk XorName, s *Section, u Uploader) {
+	for _, o := range n.observers {
+		o.OnPut(chunk, s, u)
+	}
+}
+
+func (n *Network) notifyGet(chunk XorName, s *Section) {
+	for _, o := range n.observers {
+		o.OnGet(chunk, s)
+	}
+}
+
+func (n *Network) notifyFarm(v *Vault, s *Section) {
+	for _, o := range n.observers {
+		o.OnFarm(v, s)
+	}
+}
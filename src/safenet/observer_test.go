@@ -0,0 +1,106 @@
+package safenet
+
+import (
+	"bytes"
+	"testing"
+)
+
+type call struct {
+	observer int
+	callback EventType
+	event    *MembershipEvent
+}
+
+// records every membership callback into a log shared between observers
+type recorder struct {
+	BaseObserver
+	id   int
+	log  *[]call
+	puts int
+}
+
+func (r *recorder) record(t EventType, e *MembershipEvent) {
+	*r.log = append(*r.log, call{r.id, t, e})
+}
+
+func (r *recorder) OnJoin(e *MembershipEvent)        { r.record(JoinEvent, e) }
+func (r *recorder) OnDeparture(e *MembershipEvent)   { r.record(DepartureEvent, e) }
+func (r *recorder) OnSplit(e *MembershipEvent)       { r.record(SplitEvent, e) }
+func (r *recorder) OnMerge(e *MembershipEvent)       { r.record(MergeEvent, e) }
+func (r *recorder) OnRelocate(e *MembershipEvent)    { r.record(RelocationEvent, e) }
+func (r *recorder) OnElderChange(e *MembershipEvent) { r.record(ElderChangeEvent, e) }
+
+func (r *recorder) OnPut(chunk XorName, s *Section, u Uploader) {
+	r.puts = r.puts + 1
+}
+
+func TestObserversCalledInOrder(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	log := []call{}
+	first := &recorder{id: 1, log: &log}
+	second := &recorder{id: 2, log: &log}
+	firstId := n.AddObserver(first)
+	n.AddObserver(second)
+	n.SimulateChurn(100, 300, nil)
+	if len(log) == 0 || len(log)%2 != 0 {
+		t.Fatalf("Got %d calls", len(log))
+	}
+	seen := map[int]bool{}
+	for i := 0; i < len(log); i = i + 2 {
+		a, b := log[i], log[i+1]
+		// every event goes to each observer in the order they were added
+		if a.observer != 1 || b.observer != 2 || a.event != b.event {
+			t.Fatalf("Call %d is not the same event for observers 1 and 2", i)
+		}
+		if a.callback != a.event.Type {
+			t.Errorf("%s event delivered to the %s callback", a.event.Type, a.callback)
+		}
+		if seen[a.event.Seq] || seen[a.event.Cause] {
+			t.Errorf("Event %d delivered twice or after its cause", a.event.Seq)
+		}
+		seen[a.event.Seq] = true
+	}
+	// removed observers are no longer called
+	n.RemoveObserver(firstId)
+	log = log[:0]
+	n.AddVault(NewVault(n))
+	for _, c := range log {
+		if c.observer != 2 {
+			t.Fatal("Removed observer was called")
+		}
+	}
+	c := NewConsistentClient(n)
+	c.AllocatePuts(10)
+	n.AddClient(c)
+	if !n.DoRandomPut(c, c) || second.puts != 1 || first.puts != 0 {
+		t.Error("Expected one put for the remaining observer")
+	}
+}
+
+// holds a map so it cannot be compared
+type countingObserver struct {
+	BaseObserver
+	joins map[int]bool
+}
+
+func (c countingObserver) OnJoin(e *MembershipEvent) {
+	c.joins[e.Seq] = true
+}
+
+func TestRemoveObserverOfAnyType(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	counter := countingObserver{joins: map[int]bool{}}
+	id := n.AddObserver(counter)
+	n.SetEventTrace(&bytes.Buffer{})
+	n.AddVault(NewVault(n))
+	joins := n.TotalJoins
+	n.RemoveObserver(id)
+	n.AddVault(NewVault(n))
+	if len(counter.joins) != joins {
+		t.Errorf("Expected %d joins before removing, got %d", joins, len(counter.joins))
+	}
+	// the trace is still an observer
+	if len(n.observers) != 1 || n.observers[0] != Observer(n.trace) {
+		t.Error("Removed the wrong observer")
+	}
+}
@@ -0,0 +1,67 @@
+package safenet
+
+import (
+	"fmt"
+)
+
+// Protocol parameters for a network. These change how sections split and
+// merge and how hard a section is to attack, so they are set per network
+// rather than at compile time.
+type Params struct {
+	GroupSize int `json:"group_size" usage:"number of elders in a section"`
+	// a section splits when both halves would have GroupSize + SplitBuffer
+	// adults
+	SplitBuffer int `json:"split_buffer" usage:"adults above group size needed in each half to split"`
+	// fraction of elder votes and age needed to control a section
+	QuorumNumerator   int `json:"quorum_numerator" usage:"numerator of the fraction of votes and age needed for quorum"`
+	QuorumDenominator int `json:"quorum_denominator" usage:"denominator of the fraction of votes and age needed for quorum"`
+	AdultAge          int `json:"adult_age" usage:"vaults older than this are adults"`
+	// the starting storage space for a vault is chosen randomly from this list
+	StartingStorageSizesMb []int64 `json:"starting_storage_sizes_mb"`
+}
+
+func DefaultParams() Params {
+	return Params{
+		GroupSize:         8,
+		SplitBuffer:       3,
+		QuorumNumerator:   1,
+		QuorumDenominator: 2,
+		AdultAge:          4,
+		StartingStorageSizesMb: []int64{
+			100,
+			200,
+			300,
+			400,
+			500,
+		},
+	}
+}
+
+// Returns an error for params a network cannot run with.
+func (p Params) Validate() error {
+	if p.GroupSize < 1 {
+		return fmt.Errorf("group size must be at least 1, got %d", p.GroupSize)
+	}
+	if p.SplitBuffer < 0 {
+		return fmt.Errorf("split buffer must not be negative, got %d", p.SplitBuffer)
+	}
+	if p.QuorumDenominator < 1 || p.QuorumNumerator < 0 || p.QuorumNumerator > p.QuorumDenominator {
+		return fmt.Errorf("quorum must be a fraction from 0 to 1, got %d/%d", p.QuorumNumerator, p.QuorumDenominator)
+	}
+	if p.AdultAge < 0 {
+		return fmt.Errorf("adult age must not be negative, got %d", p.AdultAge)
+	}
+	if len(p.StartingStorageSizesMb) == 0 {
+		return fmt.Errorf("at least one starting storage size is needed")
+	}
+	for _, size := range p.StartingStorageSizesMb {
+		if size < 0 {
+			return fmt.Errorf("starting storage sizes must not be negative, got %d", size)
+		}
+	}
+	return nil
+}
+
+func (p Params) SplitSize() int {
+	return p.GroupSize + p.SplitBuffer
+}
@@ -0,0 +1,44 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestDefaultParams(t *testing.T) {
+	p := DefaultParams()
+	if p.GroupSize != 8 || p.SplitBuffer != 3 || p.QuorumNumerator != 1 || p.QuorumDenominator != 2 || p.AdultAge != 4 {
+		t.Errorf("Default params are %+v", p)
+	}
+	if p.SplitSize() != 11 || len(p.StartingStorageSizesMb) != 5 {
+		t.Errorf("Default split size %d and %d storage sizes", p.SplitSize(), len(p.StartingStorageSizesMb))
+	}
+	if err := p.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParamsValidate(t *testing.T) {
+	invalid := map[string]func(p *Params){
+		"group size":     func(p *Params) { p.GroupSize = 0 },
+		"split buffer":   func(p *Params) { p.SplitBuffer = -1 },
+		"denominator":    func(p *Params) { p.QuorumDenominator = 0 },
+		"quorum above 1": func(p *Params) { p.QuorumNumerator = 3 },
+		"adult age":      func(p *Params) { p.AdultAge = -1 },
+		"no storage":     func(p *Params) { p.StartingStorageSizesMb = []int64{} },
+		"storage size":   func(p *Params) { p.StartingStorageSizesMb = []int64{100, -1} },
+	}
+	for name, change := range invalid {
+		p := DefaultParams()
+		change(&p)
+		if p.Validate() == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+	// a unanimous quorum and adults of any age are allowed
+	p := DefaultParams()
+	p.QuorumNumerator = 2
+	p.AdultAge = 0
+	if err := p.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,117 +1,144 @@
 package safenet
 
 import (
-	"strconv"
+	"math/bits"
 )
 
+// A prefix is a value type holding up to 256 bits plus a length. Bits beyond
+// the length are always zero, so two prefixes are equal exactly when == is
+// true, and prefixes can be used directly as map keys. Deriving a new prefix
+// never shares or modifies the original.
 type Prefix struct {
-	bits []bool
-	Key  string
+	bits   XorName
+	length uint16
 }
 
 func NewBlankPrefix() Prefix {
-	p := Prefix{
-		bits: []bool{},
-	}
-	p.setKey()
-	return p
+	return Prefix{}
+}
+
+func (p Prefix) Len() int {
+	return int(p.length)
+}
+
+func (p Prefix) Bit(i int) bool {
+	return p.bits.GetBit(i)
 }
 
 func (p Prefix) extendLeft() Prefix {
-	newBits := make([]bool, len(p.bits)+1)
-	for i, _ := range p.bits {
-		newBits[i] = p.bits[i]
-	}
-	l := Prefix{
-		bits: newBits,
-	}
-	l.setKey()
-	return l
+	p.length = p.length + 1
+	return p
 }
 
 func (p Prefix) extendRight() Prefix {
-	newBits := make([]bool, len(p.bits)+1)
-	for i, _ := range p.bits {
-		newBits[i] = p.bits[i]
-	}
-	newBits[len(newBits)-1] = true
-	r := Prefix{
-		bits: newBits,
+	p.bits.SetBit(int(p.length), true)
+	p.length = p.length + 1
+	return p
+}
+
+func (p Prefix) extend(b bool) Prefix {
+	if b {
+		return p.extendRight()
 	}
-	r.setKey()
-	return r
+	return p.extendLeft()
 }
 
+func (p Prefix) children() (Prefix, Prefix) {
+	return p.extendLeft(), p.extendRight()
+}
+
+// The blank prefix has no sibling and is returned unchanged.
 func (p Prefix) sibling() Prefix {
-	s := Prefix{
-		bits: p.bits,
+	if p.length == 0 {
+		return p
 	}
-	s.bits[len(s.bits)-1] = !s.bits[len(s.bits)-1]
-	s.setKey()
-	return s
+	last := int(p.length) - 1
+	p.bits.SetBit(last, !p.bits.GetBit(last))
+	return p
 }
 
+// The blank prefix has no parent and is returned unchanged.
 func (p Prefix) parent() Prefix {
-	a := Prefix{
-		bits: p.bits[:len(p.bits)-1],
+	if p.length == 0 {
+		return p
 	}
-	a.setKey()
-	return a
+	p.length = p.length - 1
+	p.bits.SetBit(int(p.length), false)
+	return p
 }
 
-func (p *Prefix) totalBytes() int {
-	// int division does floor automatically
-	totalBytes := len(p.bits) / 8
-	// but totalBytes should be ceil so do that here
-	if len(p.bits) > 0 && len(p.bits)%8 != 0 {
-		totalBytes = totalBytes + 1
+// Returns a copy of the prefix with bit i flipped, which is the prefix of the
+// neighbour differing in that bit.
+func (p Prefix) withFlippedBit(i int) Prefix {
+	p.bits.SetBit(i, !p.bits.GetBit(i))
+	return p
+}
+
+// Returns the prefix made of the first length bits of p.
+func (p Prefix) truncate(length int) Prefix {
+	if length >= int(p.length) {
+		return p
 	}
-	return totalBytes
-}
-
-func (p *Prefix) setKey() {
-	totalBytes := p.totalBytes()
-	// preallocate bytes to avoid append
-	bytes := make([]byte, totalBytes)
-	for i := 0; i < totalBytes; i++ {
-		var thisByte byte
-		startBit := i * 8
-		endBit := (i + 1) * 8
-		for j := startBit; j < endBit; j++ {
-			thisByte = thisByte << 1
-			if j < len(p.bits) && p.bits[j] {
-				thisByte = thisByte + 1
-			}
-		}
-		bytes[i] = thisByte
+	return Prefix{
+		bits:   p.bits.truncate(length),
+		length: uint16(length),
 	}
-	p.Key = strconv.Itoa(len(p.bits)) + string(bytes)
 }
 
-func (p Prefix) BinaryString() string {
-	pb := ""
-	for _, b := range p.bits {
-		if b {
-			pb = pb + "1"
-		} else {
-			pb = pb + "0"
-		}
+// Returns the name matching p which is closest to x, which is x with its
+// first bits replaced by p.
+func (p Prefix) closestName(x XorName) XorName {
+	for i := 0; i < p.Len(); i++ {
+		x.SetBit(i, p.Bit(i))
 	}
-	return pb
+	return x
+}
+
+func (p Prefix) BinaryString() string {
+	return p.bits.BinaryString()[:p.length]
 }
 
 func (p Prefix) Equals(q Prefix) bool {
-	return p.Key == q.Key
+	return p == q
 }
 
 func (p Prefix) Matches(x XorName) bool {
-	if len(p.bits) > len(x.bits) {
-		return false
+	return p.bits == x.truncate(int(p.length))
+}
+
+// Returns true if q starts with p, including when q is p.
+func (p Prefix) IsAncestorOf(q Prefix) bool {
+	return p.length <= q.length && p.bits == q.bits.truncate(int(p.length))
+}
+
+// Prefixes are compatible if one is an ancestor of the other, so their
+// sections would overlap.
+func (p Prefix) IsCompatible(q Prefix) bool {
+	return p.IsAncestorOf(q) || q.IsAncestorOf(p)
+}
+
+// Returns the number of leading bits p and q have in common.
+func (p Prefix) CommonPrefixLength(q Prefix) int {
+	common := p.bits.Xor(q.bits).LeadingZeros()
+	if common > int(p.length) {
+		common = int(p.length)
+	}
+	if common > int(q.length) {
+		common = int(q.length)
+	}
+	return common
+}
+
+// Returns how many bits differ between p and q over their common length.
+func (p Prefix) DifferingBits(q Prefix) int {
+	length := p.length
+	if q.length < length {
+		length = q.length
 	}
-	for i := 0; i < len(p.bits); i++ {
-		if p.bits[i] != x.bits[i] {
-			return false
-		}
+	x := p.bits.Xor(q.bits).truncate(int(length))
+	differing := 0
+	for _, b := range x {
+		differing = differing + bits.OnesCount8(b)
 	}
-	return true
+	return differing
 }
@@ -6,7 +6,7 @@ import (
 
 func TestNewBlankPrefix(t *testing.T) {
 	p := NewBlankPrefix()
-	if len(p.id) != 0 {
+	if p.Len() != 0 {
 		t.Error("NewBlankPrefix length is not 0")
 	}
 }
@@ -14,17 +14,17 @@ func TestNewBlankPrefix(t *testing.T) {
 func TestPrefixExtendLeft(t *testing.T) {
 	p := NewBlankPrefix()
 	p = p.extendLeft()
-	if len(p.id) != 1 {
+	if p.Len() != 1 {
 		t.Error("extendLeft once length")
 	}
-	if p.id[0] != false {
+	if p.Bit(0) != false {
 		t.Error("extendLeft once value")
 	}
 	p = p.extendLeft()
-	if len(p.id) != 2 {
+	if p.Len() != 2 {
 		t.Error("extendLeft twice length")
 	}
-	if p.id[0] != false && p.id[1] != false {
+	if p.Bit(0) != false && p.Bit(1) != false {
 		t.Error("extendLeft twice value")
 	}
 }
@@ -32,17 +32,17 @@ func TestPrefixExtendLeft(t *testing.T) {
 func TestPrefixExtendRight(t *testing.T) {
 	p := NewBlankPrefix()
 	p = p.extendRight()
-	if len(p.id) != 1 {
+	if p.Len() != 1 {
 		t.Error("extendRight once length")
 	}
-	if p.id[0] != true {
+	if p.Bit(0) != true {
 		t.Error("extendRight once value")
 	}
 	p = p.extendRight()
-	if len(p.id) != 2 {
+	if p.Len() != 2 {
 		t.Error("extendRight twice length")
 	}
-	if p.id[0] != true && p.id[1] != true {
+	if p.Bit(0) != true && p.Bit(1) != true {
 		t.Error("extendRight twice value")
 	}
 }
@@ -51,64 +51,65 @@ func TestPrefixExtendLeftAndRight(t *testing.T) {
 	p := NewBlankPrefix()
 	p = p.extendLeft()
 	p = p.extendRight()
-	if len(p.id) != 2 {
+	if p.Len() != 2 {
 		t.Error("extend Left and Right length")
 	}
-	if p.id[0] != false && p.id[1] != true {
+	if p.Bit(0) != false && p.Bit(1) != true {
 		t.Error("extend Left and Right values")
 	}
 }
 
 func TestPrefixParent(t *testing.T) {
-	p := NewBlankPrefix()
-	p.id = []bool{false, false, false, true}
+	p := NewBlankPrefix().extendLeft().extendLeft().extendLeft().extendRight()
 	p = p.parent()
-	if len(p.id) != 3 {
+	if p.Len() != 3 {
 		t.Error("parent length")
 	}
-	if p.id[2] != false {
+	if p.Bit(2) != false {
 		t.Error("parent value")
 	}
 }
 
 func TestPrefixSibling(t *testing.T) {
-	p := NewBlankPrefix()
-	p.id = []bool{false, false, false, true}
-	p = p.sibling()
-	if len(p.id) != 4 {
+	original := NewBlankPrefix().extendLeft().extendLeft().extendLeft().extendRight()
+	p := original.sibling()
+	if p.Len() != 4 {
 		t.Error("sibling length")
 	}
-	if p.id[3] != false {
+	if p.Bit(3) != false {
 		t.Error("sibling value")
 	}
+	if original.Bit(3) != true {
+		t.Error("sibling modified the original prefix")
+	}
 }
 
-func TestPrefixSetKey(t *testing.T) {
+func TestPrefixEquals(t *testing.T) {
 	p := NewBlankPrefix()
 	p = p.extendLeft()
 	q := NewBlankPrefix()
 	q = q.extendLeft()
 	q = q.extendLeft()
-	if p.Key == q.Key {
-		t.Error("setKey for 0 matches 00")
+	if p == q || p.Equals(q) {
+		t.Error("prefix 0 equals 00")
 	}
-	// key characters come directly from bytes
-	q = q.extendLeft()
-	q = q.extendLeft()
-	q = q.extendLeft()
-	q = q.extendLeft()
-	q = q.extendLeft()
-	q = q.extendLeft()
-	if len(q.Key) != 2 {
-		t.Error("setKey length for eight bits")
+	q = q.parent()
+	if p != q || !p.Equals(q) {
+		t.Error("parent of 00 does not equal 0")
 	}
-	q = q.extendLeft()
-	if len(q.Key) != 3 {
-		t.Error("setKey length for nine bits")
+	// prefixes can be used as map keys
+	m := map[Prefix]bool{p: true}
+	if !m[q] {
+		t.Error("equal prefixes have different map keys")
 	}
-	q = q.extendLeft()
-	if len(q.Key) != 4 {
-		t.Error("setKey length for ten bits")
+	// parent clears the removed bit
+	r := NewBlankPrefix().extendRight().parent()
+	if r != NewBlankPrefix() {
+		t.Error("parent of 1 does not equal blank prefix")
+	}
+	// the blank prefix has no parent or sibling
+	if NewBlankPrefix().parent() != NewBlankPrefix() || NewBlankPrefix().sibling() != NewBlankPrefix() {
+		t.Error("parent or sibling of the blank prefix is not the blank prefix")
 	}
 }
 
@@ -141,7 +142,7 @@ func TestPrefixMatches(t *testing.T) {
 	p = p.extendRight()
 	p = p.extendLeft()
 	p = p.extendLeft()
-	if len(p.id) != 8 {
+	if p.Len() != 8 {
 		t.Error("eight bits match length")
 	}
 	if !p.Matches(x) {
@@ -168,36 +169,49 @@ func TestPrefixMatches(t *testing.T) {
 	if !p.Matches(x) {
 		t.Error("sixteen bits prefix match")
 	}
+	// names are always 256 bits so the remaining bits are zero
 	p = p.extendLeft()
+	if !p.Matches(x) {
+		t.Error("seventeen bits prefix match on zero bit")
+	}
+	p = p.parent()
+	p = p.extendRight()
 	if p.Matches(x) {
-		t.Error("seventeen bits prefix match on sixteen bit name")
+		t.Error("seventeen bits prefix match on one bit")
 	}
 }
 
-func TestPrefixTotalBytes(t *testing.T) {
-	p := NewBlankPrefix()
-	if p.totalBytes() != 0 {
-		t.Error("totalBytes for 0 bits")
+func TestPrefixAncestors(t *testing.T) {
+	blank := NewBlankPrefix()
+	p := blank.extendLeft().extendRight()
+	q := p.extendRight().extendLeft()
+	if !blank.IsAncestorOf(p) || !p.IsAncestorOf(q) || !p.IsAncestorOf(p) {
+		t.Error("IsAncestorOf for ancestors")
 	}
-	p = p.extendLeft()
-	if p.totalBytes() != 1 {
-		t.Error("totalBytes for 1 bit")
+	if q.IsAncestorOf(p) || p.sibling().IsAncestorOf(q) {
+		t.Error("IsAncestorOf for non ancestors")
 	}
-	p = p.extendLeft()
-	if p.totalBytes() != 1 {
-		t.Error("totalBytes for 2 bits")
+	if !q.IsCompatible(p) || !p.IsCompatible(q) {
+		t.Error("IsCompatible for ancestors")
 	}
-	p = p.extendLeft()
-	p = p.extendLeft()
-	p = p.extendLeft()
-	p = p.extendLeft()
-	p = p.extendLeft()
-	p = p.extendLeft()
-	if p.totalBytes() != 1 {
-		t.Error("totalBytes for 8 bits")
+	if p.sibling().IsCompatible(q) {
+		t.Error("IsCompatible for sibling")
 	}
-	p = p.extendLeft()
-	if p.totalBytes() != 2 {
-		t.Error("totalBytes for 9 bits")
+}
+
+func TestPrefixCommonPrefixLength(t *testing.T) {
+	p := NewBlankPrefix().extendLeft().extendRight().extendRight()
+	q := NewBlankPrefix().extendLeft().extendRight().extendLeft().extendLeft()
+	if p.CommonPrefixLength(q) != 2 {
+		t.Error("CommonPrefixLength for differing third bit")
+	}
+	if p.CommonPrefixLength(p.parent()) != 2 {
+		t.Error("CommonPrefixLength for parent")
+	}
+	if p.CommonPrefixLength(NewBlankPrefix()) != 0 {
+		t.Error("CommonPrefixLength for blank prefix")
+	}
+	if p.DifferingBits(q) != 1 || p.DifferingBits(p.withFlippedBit(0).sibling()) != 2 {
+		t.Error("DifferingBits")
 	}
 }
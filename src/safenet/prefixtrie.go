@@ -0,0 +1,188 @@
+package safenet
+
+// Sections are stored in a binary trie keyed by the bits of their prefix.
+// Finding the section for a name walks at most one node per prefix bit, and
+// finding all sections below a prefix only visits nodes that lead to a
+// section, so lookups stay cheap for networks with millions of vaults.
+
+type prefixTrie struct {
+	root  *trieNode
+	total int
+}
+
+type trieNode struct {
+	children [2]*trieNode
+	section  *Section
+}
+
+func newPrefixTrie() prefixTrie {
+	return prefixTrie{
+		root: &trieNode{},
+	}
+}
+
+func bitIndex(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Returns the section with exactly this prefix, or nil if there is none.
+func (t *prefixTrie) get(p Prefix) *Section {
+	node := t.root
+	for i := 0; i < p.Len(); i++ {
+		node = node.children[bitIndex(p.Bit(i))]
+		if node == nil {
+			return nil
+		}
+	}
+	return node.section
+}
+
+// Stores the section at its prefix, replacing any existing section with the
+// same prefix.
+func (t *prefixTrie) insert(s *Section) {
+	node := t.root
+	for j := 0; j < s.Prefix.Len(); j++ {
+		i := bitIndex(s.Prefix.Bit(j))
+		if node.children[i] == nil {
+			node.children[i] = &trieNode{}
+		}
+		node = node.children[i]
+	}
+	if node.section == nil {
+		t.total = t.total + 1
+	}
+	node.section = s
+}
+
+// Removes the section with this prefix and prunes any nodes that no longer
+// lead to a section.
+func (t *prefixTrie) remove(p Prefix) {
+	path := make([]*trieNode, 0, p.Len()+1)
+	node := t.root
+	path = append(path, node)
+	for i := 0; i < p.Len(); i++ {
+		node = node.children[bitIndex(p.Bit(i))]
+		if node == nil {
+			return
+		}
+		path = append(path, node)
+	}
+	if node.section == nil {
+		return
+	}
+	node.section = nil
+	t.total = t.total - 1
+	// prune empty leaves back towards the root
+	for i := p.Len(); i > 0; i-- {
+		n := path[i]
+		if n.section != nil || n.children[0] != nil || n.children[1] != nil {
+			break
+		}
+		path[i-1].children[bitIndex(p.Bit(i-1))] = nil
+	}
+}
+
+// Returns the section with the longest prefix matching the name.
+// Since section prefixes do not overlap this is the only matching section.
+func (t *prefixTrie) longestMatch(x XorName) *Section {
+	node := t.root
+	match := node.section
+	for i := 0; i < xornameBits; i++ {
+		node = node.children[bitIndex(x.GetBit(i))]
+		if node == nil {
+			break
+		}
+		if node.section != nil {
+			match = node.section
+		}
+	}
+	return match
+}
+
+// Returns all sections with prefixes at or below p, ordered left to right.
+func (t *prefixTrie) subtree(p Prefix) []*Section {
+	node := t.root
+	for i := 0; i < p.Len(); i++ {
+		node = node.children[bitIndex(p.Bit(i))]
+		if node == nil {
+			return []*Section{}
+		}
+	}
+	return node.sections()
+}
+
+// Returns the sections that are ancestors of p or p itself. If there are no
+// such sections the descendants of p are returned instead.
+func (t *prefixTrie) matching(p Prefix) []*Section {
+	sections := []*Section{}
+	node := t.root
+	if node.section != nil {
+		sections = append(sections, node.section)
+	}
+	for i := 0; i < p.Len(); i++ {
+		node = node.children[bitIndex(p.Bit(i))]
+		if node == nil {
+			break
+		}
+		if node.section != nil {
+			sections = append(sections, node.section)
+		}
+	}
+	if len(sections) == 0 {
+		sections = t.subtree(p)
+	}
+	return sections
+}
+
+// Returns the sections covering the sibling of p, which is the set of sections
+// p would merge with.
+func (t *prefixTrie) siblings(p Prefix) []*Section {
+	if p.Len() == 0 {
+		return []*Section{}
+	}
+	return t.matching(p.sibling())
+}
+
+// Returns the sections covering each prefix that differs from p in exactly
+// one bit, ordered by the position of the differing bit.
+func (t *prefixTrie) neighbours(p Prefix) [][]*Section {
+	neighbours := [][]*Section{}
+	for i := 0; i < p.Len(); i++ {
+		neighbours = append(neighbours, t.matching(p.withFlippedBit(i)))
+	}
+	return neighbours
+}
+
+// Returns every section in the trie ordered left to right.
+func (t *prefixTrie) all() []*Section {
+	return t.root.sections()
+}
+
+func (t *prefixTrie) len() int {
+	return t.total
+}
+
+// Collects sections at or below this node without recursion, since the trie
+// may be very deep in places.
+func (node *trieNode) sections() []*Section {
+	sections := []*Section{}
+	stack := []*trieNode{node}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.section != nil {
+			sections = append(sections, n.section)
+		}
+		// push right first so left is visited first
+		if n.children[1] != nil {
+			stack = append(stack, n.children[1])
+		}
+		if n.children[0] != nil {
+			stack = append(stack, n.children[0])
+		}
+	}
+	return sections
+}
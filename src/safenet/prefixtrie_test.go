@@ -0,0 +1,109 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestPrefixTrieLookups(t *testing.T) {
+	trie := newPrefixTrie()
+	// sections 0, 10, 110, 111
+	p0 := NewBlankPrefix().extendLeft()
+	p10 := NewBlankPrefix().extendRight().extendLeft()
+	p110 := NewBlankPrefix().extendRight().extendRight().extendLeft()
+	p111 := NewBlankPrefix().extendRight().extendRight().extendRight()
+	for _, p := range []Prefix{p0, p10, p110, p111} {
+		trie.insert(&Section{Prefix: p})
+	}
+	if trie.len() != 4 {
+		t.Error("len after insert")
+	}
+	if trie.get(p10) == nil || trie.get(p10.parent()) != nil {
+		t.Error("get exact prefix")
+	}
+	// 1101 0000 matches section 110
+	x := XorName{0xD0}
+	if trie.longestMatch(x).Prefix != p110 {
+		t.Error("longestMatch")
+	}
+	// everything below 11
+	below := trie.subtree(p110.parent())
+	if len(below) != 2 || below[0].Prefix != p110 || below[1].Prefix != p111 {
+		t.Error("subtree ordering")
+	}
+	// sibling of 0 is covered by 10, 110 and 111
+	if len(trie.siblings(p0)) != 3 {
+		t.Error("siblings covering children")
+	}
+	// neighbours of 110 are 010 (covered by 0), 100 (covered by 10) and 111
+	neighbours := trie.neighbours(p110)
+	if neighbours[0][0].Prefix != p0 || neighbours[1][0].Prefix != p10 || neighbours[2][0].Prefix != p111 {
+		t.Error("neighbours")
+	}
+	// removing prunes nodes so the parent prefix finds the remaining child
+	trie.remove(p111)
+	if trie.len() != 3 || len(trie.subtree(p111.parent())) != 1 {
+		t.Error("remove")
+	}
+}
+
+func TestPrefixTrieSingleSection(t *testing.T) {
+	trie := newPrefixTrie()
+	blank := NewBlankPrefix()
+	if trie.longestMatch(XorName{}) != nil || len(trie.all()) != 0 {
+		t.Error("empty trie has sections")
+	}
+	s := &Section{Prefix: blank}
+	trie.insert(s)
+	// the blank prefix matches every name and is an ancestor of every prefix
+	if trie.longestMatch(XorName{0xFF}) != s || trie.get(blank) != s {
+		t.Error("blank prefix does not match")
+	}
+	p := blank.extendRight().extendLeft()
+	if matching := trie.matching(p); len(matching) != 1 || matching[0] != s {
+		t.Error("matching with an ancestor")
+	}
+	// replacing a section keeps the count
+	r := &Section{Prefix: blank}
+	trie.insert(r)
+	if trie.len() != 1 || trie.get(blank) != r {
+		t.Error("insert does not replace")
+	}
+	// removing a missing prefix does nothing
+	trie.remove(p)
+	if trie.len() != 1 {
+		t.Error("removed a missing prefix")
+	}
+	trie.remove(blank)
+	if trie.len() != 0 || trie.longestMatch(XorName{}) != nil {
+		t.Error("remove last section")
+	}
+}
+
+func TestPrefixTrieMatchingDescendants(t *testing.T) {
+	trie := newPrefixTrie()
+	// sections 00, 010, 011 and 1
+	p00 := NewBlankPrefix().extendLeft().extendLeft()
+	p010 := NewBlankPrefix().extendLeft().extendRight().extendLeft()
+	p011 := NewBlankPrefix().extendLeft().extendRight().extendRight()
+	p1 := NewBlankPrefix().extendRight()
+	for _, p := range []Prefix{p011, p1, p00, p010} {
+		trie.insert(&Section{Prefix: p})
+	}
+	// all sections are ordered left to right whatever the insert order
+	all := trie.all()
+	if len(all) != 4 || all[0].Prefix != p00 || all[1].Prefix != p010 || all[2].Prefix != p011 || all[3].Prefix != p1 {
+		t.Error("all ordering")
+	}
+	// 0 has no section or ancestor section, so its descendants match
+	if len(trie.matching(p1.sibling())) != 3 {
+		t.Error("matching descendants")
+	}
+	// 0101 is covered by its ancestor 010
+	p0101 := p010.extendRight()
+	if matching := trie.matching(p0101); len(matching) != 1 || matching[0].Prefix != p010 {
+		t.Error("matching ancestor")
+	}
+	if len(trie.subtree(p0101)) != 0 {
+		t.Error("subtree below a leaf")
+	}
+}
@@ -0,0 +1,96 @@
+package safenet
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+)
+
+// Each part of the simulation draws from its own stream so that changing the
+// number of draws in one subsystem does not disturb the others. For example
+// adding a client type which draws one extra number will not change the names
+// of vaults or the hash of later network events.
+type randomStreams struct {
+	names   *rand.Rand // names of new vaults
+	events  *rand.Rand // NetworkEvent hashes
+	churn   *rand.Rand // selecting vaults and sections for departure
+	clients *rand.Rand // client types, ids and behaviour, chunk names
+	farming *rand.Rand // farm rate tests and safecoin allocation
+	// names of relocated vaults and targets for strategies which choose
+	// randomly
+	relocation *rand.Rand
+	// names for sampling routes between
+	routing *rand.Rand
+	// starting storage sizes of vaults
+	storage *rand.Rand
+	// the source for each stream in the order above, kept so the state of
+	// every stream can be saved and restored
+	sources []*prngSource
+}
+
+var randomStreamNames = []string{"names", "events", "churn", "clients", "farming", "relocation", "routing", "storage"}
+
+func newRandomStreams(seed int64) randomStreams {
+	r := randomStreams{
+		sources: make([]*prngSource, len(randomStreamNames)),
+	}
+	streams := []**rand.Rand{&r.names, &r.events, &r.churn, &r.clients, &r.farming, &r.relocation, &r.routing, &r.storage}
+	for i, name := range randomStreamNames {
+		r.sources[i] = newPrngSource(streamSeed(seed, name))
+		*streams[i] = rand.New(r.sources[i])
+	}
+	return r
+}
+
+// Derives the seed for a stream by hashing the master seed with the name of
+// the stream, so every stream is independent but fully determined by the
+// master seed.
+func streamSeed(seed int64, name string) int64 {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(seed))
+	h := sha256.Sum256(append(b, []byte(name)...))
+	return int64(binary.BigEndian.Uint64(h[:8]))
+}
+
+// Returns the current state of every stream
+func (r *randomStreams) state() []uint64 {
+	state := make([]uint64, len(r.sources))
+	for i, s := range r.sources {
+		state[i] = s.state
+	}
+	return state
+}
+
+func (r *randomStreams) setState(state []uint64) {
+	for i, s := range r.sources {
+		s.state = state[i]
+	}
+}
+
+// A splitmix64 generator. Unlike the source from rand.NewSource the entire
+// state is a single value, so a network can be saved and restored mid run.
+type prngSource struct {
+	state uint64
+}
+
+func newPrngSource(seed int64) *prngSource {
+	return &prngSource{
+		state: uint64(seed),
+	}
+}
+
+func (s *prngSource) Uint64() uint64 {
+	s.state = s.state + 0x9E3779B97F4A7C15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+func (s *prngSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *prngSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
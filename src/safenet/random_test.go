@@ -0,0 +1,79 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestVaultStorageHasItsOwnStream(t *testing.T) {
+	a := NewNetworkFromSeed(1, DefaultParams())
+	b := NewNetworkFromSeed(1, DefaultParams())
+	for i := 0; i < 10; i++ {
+		NewVaultForOperator(a, nil)
+	}
+	if newXorName(a.rng.clients) != newXorName(b.rng.clients) {
+		t.Error("Creating vaults changed the client stream")
+	}
+}
+
+func TestNewVaultNamesHaveTheirOwnStream(t *testing.T) {
+	a := NewNetworkFromSeed(1, DefaultParams())
+	b := NewNetworkFromSeed(1, DefaultParams())
+	v := NewVault(a)
+	NewVault(b)
+	// churn and relocate in one network only
+	for i := 0; i < 10; i++ {
+		a.rng.churn.Uint64()
+		v.renameWithPrefix(a, NewBlankPrefix())
+	}
+	if NewVault(a).Name != NewVault(b).Name {
+		t.Error("Churn and relocation changed the names of new vaults")
+	}
+}
+
+// one churn event on a network kept at about 100 vaults
+func churnOnce(n *Network) {
+	n.AddVault(NewVault(n))
+	if n.TotalVaults() > 100 {
+		n.RemoveVault(n.GetRandomVault())
+	}
+}
+
+func vaultNames(n *Network) []XorName {
+	names := []XorName{}
+	for _, v := range n.vaults.vaults {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func sameNames(a, b []XorName) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNetworksInOneProcessAreIndependent(t *testing.T) {
+	alone := NewNetworkFromSeed(1, DefaultParams())
+	for i := 0; i < 500; i++ {
+		churnOnce(alone)
+	}
+	// the same seed alongside a network with another seed, event by event
+	same := NewNetworkFromSeed(1, DefaultParams())
+	other := NewNetworkFromSeed(2, DefaultParams())
+	for i := 0; i < 500; i++ {
+		churnOnce(same)
+		churnOnce(other)
+	}
+	if !sameNames(vaultNames(same), vaultNames(alone)) || same.TotalRelocations != alone.TotalRelocations {
+		t.Error("Running another network alongside changed the run")
+	}
+	if sameNames(vaultNames(other), vaultNames(alone)) {
+		t.Error("Different seeds gave the same run")
+	}
+}
@@ -0,0 +1,134 @@
+package safenet
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+)
+
+// Chooses the section a vault is relocated to. The vault is still in its old
+// section when Target is called. Returning the old section keeps the vault
+// in place apart from its new name and age.
+type RelocationStrategy interface {
+	Target(n *Network, v *Vault, ne *NetworkEvent) *Section
+}
+
+// Names of the relocation strategies for NewRelocationStrategy
+const (
+	SmallestNeighbourRelocationName = "smallest-neighbour"
+	ClosestToHashRelocationName     = "closest-to-hash"
+	RandomNeighbourRelocationName   = "random-neighbour"
+	HashAnywhereRelocationName      = "hash-anywhere"
+)
+
+func NewRelocationStrategy(name string) (RelocationStrategy, error) {
+	switch name {
+	case "", SmallestNeighbourRelocationName:
+		return SmallestNeighbourRelocation{}, nil
+	case ClosestToHashRelocationName:
+		return ClosestToHashRelocation{}, nil
+	case RandomNeighbourRelocationName:
+		return RandomNeighbourRelocation{}, nil
+	case HashAnywhereRelocationName:
+		return HashAnywhereRelocation{}, nil
+	}
+	return nil, fmt.Errorf("unknown relocation strategy %s, use %s, %s, %s or %s", name, SmallestNeighbourRelocationName, ClosestToHashRelocationName, RandomNeighbourRelocationName, HashAnywhereRelocationName)
+}
+
+// The neighbour with the shortest prefix, then the fewest vaults. This is the
+// default when Network.Relocation is nil.
+type SmallestNeighbourRelocation struct{}
+
+func (r SmallestNeighbourRelocation) Target(n *Network, v *Vault, ne *NetworkEvent) *Section {
+	// find the neighbour with shortest prefix or fewest vaults
+	// default to the existing section, useful for zero-length prefix
+	smallestNeighbour := n.sections.get(v.Prefix)
+	minNeighbourPrefix := math.MaxUint32
+	minNeighbourVaults := math.MaxUint32
+	// get all neighbours
+	for i := 0; i < v.Prefix.Len(); i++ {
+		// copy the prefix but flip the ith bit of the prefix
+		neighbourPrefix := v.Prefix.withFlippedBit(i)
+		// get neighbouring prefixes from the network for this prefix
+		// and repeat until we arrive at the 'best' neighbour prefix
+		prevNeighbourPrefix := NewBlankPrefix()
+		for !neighbourPrefix.Equals(prevNeighbourPrefix) {
+			// track previous neighbour prefix
+			prevNeighbourPrefix = neighbourPrefix
+			// get potential new neighbour prefixes
+			neighbourPrefixes := n.getMatchingPrefixes(neighbourPrefix)
+			// check if these neighbours contain the 'best' neighbour
+			// prioritise sections with shorter prefixes and having less nodes to balance the network
+			for _, p := range neighbourPrefixes {
+				s := n.sections.get(p)
+				if p.Len() < minNeighbourPrefix {
+					// prefer shorter prefixes
+					neighbourPrefix = p
+					minNeighbourPrefix = p.Len()
+					smallestNeighbour = s
+				} else if p.Len() == minNeighbourPrefix {
+					// prefer less vaults if prefix length is same
+					if len(s.Vaults) < minNeighbourVaults {
+						neighbourPrefix = p
+						minNeighbourVaults = len(s.Vaults)
+						smallestNeighbour = s
+					} else if len(s.Vaults) == minNeighbourVaults {
+						// TODO tiebreaker for equal sized neighbours
+						// see https://forum.safedev.org/t/data-chains-deeper-dive/1209
+						// If all neighbours have the same number of peers we relocate
+						// to the section closest to the H above (that is not us)
+					}
+				}
+			}
+		}
+	}
+	return smallestNeighbour
+}
+
+// The data-chains rule, the neighbour closest to the event hash H. Our own
+// section is never chosen unless it has no neighbours.
+// see https://forum.safedev.org/t/data-chains-deeper-dive/1209
+type ClosestToHashRelocation struct{}
+
+func (r ClosestToHashRelocation) Target(n *Network, v *Vault, ne *NetworkEvent) *Section {
+	target := n.sections.get(v.Prefix)
+	var closest XorName
+	for i, s := range n.neighbourSections(v.Prefix) {
+		d := s.Prefix.closestName(ne.hash).Xor(ne.hash)
+		if i == 0 || d.IsLessThan(closest) {
+			closest = d
+			target = s
+		}
+	}
+	return target
+}
+
+// A neighbour chosen uniformly at random.
+type RandomNeighbourRelocation struct{}
+
+func (r RandomNeighbourRelocation) Target(n *Network, v *Vault, ne *NetworkEvent) *Section {
+	neighbours := n.neighbourSections(v.Prefix)
+	if len(neighbours) == 0 {
+		return n.sections.get(v.Prefix)
+	}
+	return neighbours[n.rng.relocation.Intn(len(neighbours))]
+}
+
+// The section holding the hash of the vault name and the event hash, which
+// can be anywhere in the network including the vault's own section.
+type HashAnywhereRelocation struct{}
+
+func (r HashAnywhereRelocation) Target(n *Network, v *Vault, ne *NetworkEvent) *Section {
+	var target XorName = sha256.Sum256(append(v.Name[:], ne.hash[:]...))
+	return n.sections.longestMatch(target)
+}
+
+// Returns the sections whose prefix differs from p in exactly one bit over
+// their common length, ordered by the bit that differs then by prefix.
+func (n *Network) neighbourSections(p Prefix) []*Section {
+	neighbours := []*Section{}
+	for _, sections := range n.sections.neighbours(p) {
+		neighbours = append(neighbours, sections...)
+	}
+	return neighbours
+}
@@ -0,0 +1,46 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestNeighbourRelocationTargets(t *testing.T) {
+	n := buildTestNetwork(1, 2000)
+	strategies := []RelocationStrategy{
+		SmallestNeighbourRelocation{},
+		ClosestToHashRelocation{},
+		RandomNeighbourRelocation{},
+	}
+	for _, s := range n.Sections() {
+		v := s.Vaults[0]
+		ne := NewNetworkEvent(n)
+		for _, r := range strategies {
+			target := r.Target(n, v, ne)
+			if target == s || target.Prefix.DifferingBits(s.Prefix) != 1 {
+				t.Errorf("%T relocates from %s to %s", r, s.Prefix.BinaryString(), target.Prefix.BinaryString())
+			}
+		}
+	}
+}
+
+func TestClosestToHashRelocation(t *testing.T) {
+	n := buildTestNetwork(1, 2000)
+	s := n.Sections()[0]
+	v := s.Vaults[0]
+	ne := NewNetworkEvent(n)
+	r := ClosestToHashRelocation{}
+	target := r.Target(n, v, ne)
+	// no other neighbour is closer
+	closest := target.Prefix.closestName(ne.hash).Xor(ne.hash)
+	for _, neighbour := range n.neighbourSections(s.Prefix) {
+		if neighbour.Prefix.closestName(ne.hash).Xor(ne.hash).IsLessThan(closest) {
+			t.Errorf("%s is closer than %s", neighbour.Prefix.BinaryString(), target.Prefix.BinaryString())
+		}
+	}
+	// a hash within a neighbour chooses that neighbour
+	neighbour := n.neighbourSections(s.Prefix)[0]
+	ne.hash = neighbour.Prefix.closestName(ne.hash)
+	if r.Target(n, v, ne) != neighbour {
+		t.Error("Neighbour holding the hash was not chosen")
+	}
+}
@@ -0,0 +1,96 @@
+package safenet
+
+// Messages are routed between sections through their neighbours. Every hop
+// goes to the neighbour closest to the destination by XOR distance, which
+// always shares at least one more leading bit with the destination, so a
+// route takes at most as many hops as the prefix of its first section has
+// bits. Routing tables are built from the sections when they are needed, so
+// they always match the current network.
+
+// A section's routing table, the sections covering each prefix which differs
+// from its own in exactly one bit, ordered by the bit that differs. A
+// neighbour which has split further than the section has several entries for
+// its bit.
+type RoutingTable struct {
+	Prefix     Prefix
+	Neighbours [][]*Section
+}
+
+// The number of sections in the table
+func (t RoutingTable) Len() int {
+	total := 0
+	for _, sections := range t.Neighbours {
+		total = total + len(sections)
+	}
+	return total
+}
+
+func (n *Network) routingTable(s *Section) RoutingTable {
+	return RoutingTable{
+		Prefix:     s.Prefix,
+		Neighbours: n.sections.neighbours(s.Prefix),
+	}
+}
+
+// Returns the routing table of every section, ordered by prefix.
+func (n *Network) RoutingTables() []RoutingTable {
+	tables := []RoutingTable{}
+	for _, s := range n.sections.all() {
+		tables = append(tables, n.routingTable(s))
+	}
+	return tables
+}
+
+// Returns the distance from the closest name the section is responsible for
+// to x, which is zero if the section is responsible for x.
+func (s *Section) distanceTo(x XorName) XorName {
+	return s.Prefix.closestName(x).Xor(x)
+}
+
+// Routes a message greedily from the section responsible for from to the
+// section responsible for to. Returns the sections the message passes
+// through including both ends, so the route takes one hop less than the
+// number of sections. Returns ErrNoRoute with the sections visited so far if
+// no neighbour is closer to the destination, which does not happen while
+// sections cover the whole namespace.
+func (n *Network) Route(from, to XorName) ([]*Section, error) {
+	current := n.sections.longestMatch(from)
+	if current == nil {
+		return []*Section{}, ErrNoSectionForName
+	}
+	path := []*Section{current}
+	for !current.Prefix.Matches(to) {
+		next := current
+		closest := current.distanceTo(to)
+		for _, sections := range n.routingTable(current).Neighbours {
+			for _, s := range sections {
+				d := s.distanceTo(to)
+				if d.IsLessThan(closest) {
+					closest = d
+					next = s
+				}
+			}
+		}
+		if next == current {
+			return path, ErrNoRoute
+		}
+		current = next
+		path = append(path, current)
+	}
+	return path, nil
+}
+
+// Routes count messages between random names, adding the hops of each route
+// to hops. Routes which fail are counted as warnings and not added.
+func (n *Network) SampleRoutes(count int, hops Histogram) {
+	for i := 0; i < count; i++ {
+		from := newXorName(n.rng.routing)
+		to := newXorName(n.rng.routing)
+		path, err := n.Route(from, to)
+		if err != nil {
+			n.warn(err)
+			continue
+		}
+		hops.Add(len(path) - 1)
+	}
+}
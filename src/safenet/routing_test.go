@@ -0,0 +1,42 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestRouteReachesDestination(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	n.SimulateChurn(2000, 2000, nil)
+	for _, table := range n.RoutingTables() {
+		if table.Len() != len(n.neighbourSections(table.Prefix)) || len(table.Neighbours) != table.Prefix.Len() {
+			t.Fatalf("Routing table of %s has %d sections", table.Prefix.BinaryString(), table.Len())
+		}
+	}
+	for i := 0; i < 200; i++ {
+		from := newXorName(n.rng.routing)
+		to := newXorName(n.rng.routing)
+		path, err := n.Route(from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !path[0].Prefix.Matches(from) || !path[len(path)-1].Prefix.Matches(to) {
+			t.Fatal("Route does not join the sections of its ends")
+		}
+		if len(path)-1 > path[0].Prefix.Len() {
+			t.Errorf("Route took %d hops from prefix %s", len(path)-1, path[0].Prefix.BinaryString())
+		}
+		for j := 1; j < len(path); j++ {
+			if path[j].Prefix.DifferingBits(path[j-1].Prefix) != 1 {
+				t.Fatal("Route hop is not to a neighbour")
+			}
+			if !path[j].distanceTo(to).IsLessThan(path[j-1].distanceTo(to)) {
+				t.Fatal("Route hop is not closer to the destination")
+			}
+		}
+	}
+	hops := Histogram{}
+	n.SampleRoutes(100, hops)
+	if len(hops) == 0 || n.WarningCount(ErrNoRoute) > 0 {
+		t.Error("Expected routes without warnings")
+	}
+}
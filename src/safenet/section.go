@@ -1,38 +1,42 @@
 package safenet
 
-import (
-	"fmt"
-	"math/big"
-	"sort"
-)
-
 type Section struct {
 	Prefix    Prefix
 	Vaults    []*Vault
 	Uploaders map[string]bool
+	network   *Network
+	// the GroupSize oldest vaults, oldest first, see updateElders
+	elderSet []*Vault
+	// the same vaults as Vaults, oldest first
+	byAge ageIndex
 }
 
 // Returns a slice of sections since as vaults age they may cascade into
 // multiple sections.
-func newSection(prefix Prefix, vaults []*Vault) *NetworkEvent {
+// t is the event creating the section, used for its NetworkEvent hash.
+func newSection(n *Network, t EventType, prefix Prefix, vaults []*Vault) *NetworkEvent {
 	s := Section{
 		Prefix:    prefix,
 		Vaults:    []*Vault{},
 		Uploaders: map[string]bool{},
+		network:   n,
 	}
 	// add each existing vault to new section
 	for _, v := range vaults {
-		v.SetPrefix(s.Prefix)
-		s.Vaults = append(s.Vaults, v)
+		n.warn(v.SetPrefix(s.Prefix))
+		s.appendVault(v)
 	}
 	// split into two sections if needed.
 	// there is no vault relocation here.
 	if s.shouldSplit() {
-		return s.split()
+		ne, err := s.split()
+		n.warn(err)
+		return ne
 	}
+	s.updateElders()
 	// return the section as a network event.
 	// there is a vault relocation here.
-	ne := NewNetworkEvent()
+	ne := n.newNetworkEvent(t, prefix, nil)
 	ne.NewSections = []*Section{&s}
 	v := s.vaultForRelocation(ne)
 	if v != nil {
@@ -41,18 +45,10 @@ func newSection(prefix Prefix, vaults []*Vault) *NetworkEvent {
 	return ne
 }
 
-func (s *Section) addVault(v *Vault) (*NetworkEvent, bool) {
-	// disallow more than one node aged 1 per section if the section is
-	// TODO currently disabled, should be reenabled
-	// complete (all elders are adults)
-	// see https://github.com/fizyk20/ageing_sim/blob/53829350daa372731c9b8080488b2a75c72f60bb/src/network/section.rs#L198
-	isDisallowed := false
-	//if v.Age == 1 && s.hasVaultAgedOne() && s.isComplete() {
-	//	isDisallowed = true
-	//	return nil, isDisallowed
-	//}
-	v.SetPrefix(s.Prefix)
-	s.Vaults = append(s.Vaults, v)
+// Vaults are admitted by the network's admission policy before being added.
+func (s *Section) addVault(v *Vault) *NetworkEvent {
+	s.network.warn(v.SetPrefix(s.Prefix))
+	s.appendVault(v)
 	// set chunks for this vault
 	// TODO this can be improved. Currently it works based on all vaults
 	// store all chunks, so just take the first vault and duplicate the
@@ -72,17 +68,29 @@ func (s *Section) addVault(v *Vault) (*NetworkEvent, bool) {
 	// split into two sections if needed
 	// details are handled by network upon returning two new sections
 	if s.shouldSplit() {
-		return s.split(), isDisallowed
+		ne, err := s.split()
+		s.network.warn(err)
+		return ne
 	}
+	s.updateElders()
 	// no split so return zero new sections
 	// but a new vault added triggers a network event which may lead to vault
 	// relocation
-	ne := NewNetworkEvent()
+	ne := s.network.newNetworkEvent(JoinEvent, s.Prefix, v)
 	r := s.vaultForRelocation(ne)
 	if r != nil {
 		ne.VaultToRelocate = r
 	}
-	return ne, isDisallowed
+	return ne
+}
+
+func (s *Section) hasVault(v *Vault) bool {
+	for _, vault := range s.Vaults {
+		if vault == v {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *Section) removeVault(v *Vault) *NetworkEvent {
@@ -91,15 +99,11 @@ func (s *Section) removeVault(v *Vault) *NetworkEvent {
 	//for _, chunk := range v.Chunks {
 	//}
 	// remove from section
-	for i, vault := range s.Vaults {
-		if vault == v {
-			s.Vaults = append(s.Vaults[:i], s.Vaults[i+1:]...)
-			break
-		}
-	}
+	s.deleteVault(v)
+	s.updateElders()
 	// merge is handled by network using NetworkEvent ne
 	// which includes a vault relocation
-	ne := NewNetworkEvent()
+	ne := s.network.newNetworkEvent(DepartureEvent, s.Prefix, v)
 	r := s.vaultForRelocation(ne)
 	if r != nil {
 		ne.VaultToRelocate = r
@@ -107,62 +111,56 @@ func (s *Section) removeVault(v *Vault) *NetworkEvent {
 	return ne
 }
 
-func (s *Section) split() *NetworkEvent {
+// Returns ErrPrefixMismatch if a vault doesn't match either half, in which
+// case the vault is dropped from the section and listed in DroppedVaults so
+// the network can record its departure.
+func (s *Section) split() (*NetworkEvent, error) {
 	leftPrefix := s.Prefix.extendLeft()
 	rightPrefix := s.Prefix.extendRight()
 	left := []*Vault{}
 	right := []*Vault{}
+	dropped := []*Vault{}
+	var err error
 	for _, v := range s.Vaults {
 		if leftPrefix.Matches(v.Name) {
 			left = append(left, v)
 		} else if rightPrefix.Matches(v.Name) {
 			right = append(right, v)
 		} else {
-			fmt.Println("Warning: Split has vault that doesn't match extended prefix")
+			dropped = append(dropped, v)
+			err = ErrPrefixMismatch
 		}
 	}
-	ne0 := newSection(leftPrefix, left)
-	ne1 := newSection(rightPrefix, right)
-	ne := NewNetworkEvent()
+	ne0 := newSection(s.network, SplitEvent, leftPrefix, left)
+	ne1 := newSection(s.network, SplitEvent, rightPrefix, right)
+	ne := s.network.newNetworkEvent(SplitEvent, s.Prefix, nil)
 	ne.NewSections = []*Section{}
 	ne.NewSections = append(ne.NewSections, ne0.NewSections...)
 	ne.NewSections = append(ne.NewSections, ne1.NewSections...)
-	return ne
+	ne.DroppedVaults = append(dropped, ne0.DroppedVaults...)
+	ne.DroppedVaults = append(ne.DroppedVaults, ne1.DroppedVaults...)
+	return ne, err
 }
 
 func (s *Section) shouldSplit() bool {
 	left := s.leftAdultCount()
 	right := s.rightAdultCount()
-	return left >= SplitSize && right >= SplitSize
+	splitSize := s.network.Params.SplitSize()
+	return left >= splitSize && right >= splitSize
 }
 
 func (s *Section) isComplete() bool {
 	// GROUP_SIZE peers with age >4 in a section
-	return s.TotalAdults() >= GroupSize
+	return s.TotalAdults() >= s.network.Params.GroupSize
 }
 
 func (s *Section) hasVaultAgedOne() bool {
-	for _, v := range s.Vaults {
-		if v.Age == 1 {
-			return true
-		}
-	}
-	return false
+	younger := s.byAge.agedAtMost(1)
+	return len(younger) > 0 && younger[0].Age == 1
 }
 
 func (s *Section) elders() []*Vault {
-	// get elders
-	// see https://forum.safedev.org/t/data-chains-deeper-dive/1209
-	// the GROUP_SIZE oldest peers in the section
-	// tiebreakers are handled by the sort algorithm
-	sort.Sort(oldestFirst(s.Vaults))
-	// if there aren't enough vaults, use all of them
-	elders := s.Vaults
-	// otherwise get the GroupSize oldest vaults
-	if len(s.Vaults) > GroupSize {
-		elders = s.Vaults[:GroupSize]
-	}
-	return elders
+	return s.elderSet
 }
 
 func (s *Section) IsAttacked() bool {
@@ -184,81 +182,42 @@ func (s *Section) IsAttacked() bool {
 	}
 	// use integer arithmetic to check quorum
 	// see https://github.com/maidsafe/routing/blob/da462bfebfd47dd16cb0c7523359d219bb097a3e/src/lib.rs#L213
-	votesAttacked := attackingVotes*QuorumDenominator > totalVotes*QuorumNumerator
+	p := s.network.Params
+	votesAttacked := attackingVotes*p.QuorumDenominator > totalVotes*p.QuorumNumerator
 	// compare ages
-	ageAttacked := attackingAge*QuorumDenominator > totalAge*QuorumNumerator
+	ageAttacked := attackingAge*p.QuorumDenominator > totalAge*p.QuorumNumerator
 	return votesAttacked && ageAttacked
 }
 
-func (s *Section) GetRandomVault() *Vault {
+func (s *Section) GetRandomVault() (*Vault, error) {
 	totalVaults := len(s.Vaults)
 	if totalVaults == 0 {
-		fmt.Println("Warning: GetRandomVault for section with no vaults")
-		return nil
+		return nil, ErrEmptySection
 	}
-	i := prng.Intn(totalVaults)
-	return s.Vaults[i]
+	i := s.network.rng.churn.Intn(totalVaults)
+	return s.Vaults[i], nil
 }
 
+// Chooses the vault to relocate using the network's relocation trigger.
 func (s *Section) vaultForRelocation(ne *NetworkEvent) *Vault {
-	// find vault to relocate based on a randomly generated 'event hash'
-	// see https://forum.safedev.org/t/data-chains-deeper-dive/1209
-	// As we receive/form a valid block of Live for non-infant peers, we take
-	// the Hash of the event H. Then if H % 2^age == 0 for any peer (sorted by
-	// age ascending) in our section, we relocate this node to the neighbour
-	// that has the lowest number of peers.
-	oldestAge := 0
-	smallestTiebreaker := big.NewInt(0).SetBytes(largestHashValue)
-	var v *Vault
-	for _, w := range s.Vaults {
-		if w.Age < oldestAge {
-			continue
-		} else if w.Age > oldestAge {
-			// calculate divisor as 2^age
-			divisor := big.NewInt(1)
-			divisor.Lsh(divisor, uint(w.Age))
-			if ne.HashModIsZero(divisor) {
-				oldestAge = w.Age
-				v = w
-				// track xordistance for potential future tiebreaker
-				xordistance := big.NewInt(0)
-				xordistance.Xor(w.Name.bigint, ne.hash)
-				smallestTiebreaker = xordistance
-			}
-		} else if w.Age == oldestAge {
-			// calculate divisor as 2^age
-			divisor := big.NewInt(1)
-			divisor.Lsh(divisor, uint(w.Age))
-			if ne.HashModIsZero(divisor) {
-				// tiebreaker
-				// If there are multiple peers of the same age then XOR their
-				// public keys together and find the one XOR closest to it.
-				// TODO this isn't done correctly, since it only XORs the two
-				// keys when it should XOR all keys of this age.
-				xordistance := big.NewInt(0)
-				xordistance.Xor(w.Name.bigint, ne.hash)
-				if xordistance.Cmp(smallestTiebreaker) == -1 {
-					smallestTiebreaker = xordistance
-					v = w
-				}
-			}
-		}
+	trigger := s.network.RelocationTrigger
+	if trigger == nil {
+		trigger = HashAgeTrigger{}
 	}
-	return v
+	return trigger.VaultToRelocate(s, ne)
 }
 
 func (s *Section) shouldMerge() bool {
-	return s.TotalAdults() <= GroupSize
+	return s.TotalAdults() <= s.network.Params.GroupSize
 }
 
 func (s *Section) TotalAdults() int {
-	adults := 0
-	for _, v := range s.Vaults {
-		if v.IsAdult() {
-			adults = adults + 1
-		}
-	}
-	return adults
+	return len(s.adults())
+}
+
+// Returns the adults, oldest first.
+func (s *Section) adults() []*Vault {
+	return s.byAge[:len(s.byAge)-len(s.byAge.agedAtMost(s.network.Params.AdultAge))]
 }
 
 func (s *Section) TotalElders() int {
@@ -297,8 +256,8 @@ func (s *Section) rightAdultCount() int {
 
 func (s *Section) adultCountForExtendedPrefix(p Prefix) int {
 	adults := 0
-	for _, v := range s.Vaults {
-		if v.IsAdult() && p.Matches(v.Name) {
+	for _, v := range s.adults() {
+		if p.Matches(v.Name) {
 			adults = adults + 1
 		}
 	}
@@ -374,7 +333,7 @@ func (s *Section) SafecoinPerMb() float64 {
 	// see https://github.com/maidsafe/rfcs/blob/master/text/0012-safecoin-implementation/0012-safecoin-implementation.md#establishing-storecost
 	farmRate := 1.0 / float64(s.FarmDivisor())
 	totalNumberOfClientAccounts := float64(s.TotalClients())
-	storeCost := farmRate * totalNumberOfClientAccounts / float64(GroupSize)
+	storeCost := farmRate * totalNumberOfClientAccounts / float64(s.network.Params.GroupSize)
 	return storeCost
 }
 
@@ -386,13 +345,19 @@ func (s *Section) TotalClients() int64 {
 	return total
 }
 
-// Decide which vault wins the race for the GET and thus receives the safecoin
-func (s *Section) AllocateSafecoin() {
-	i := prng.Intn(len(s.Vaults))
+// Decide which vault wins the race for the GET and thus receives the safecoin.
+// Returns ErrNilOperator if the winning vault has no operator to pay, in
+// which case no coin is allocated.
+func (s *Section) AllocateSafecoin() error {
+	if len(s.Vaults) == 0 {
+		return ErrEmptySection
+	}
+	i := s.network.rng.farming.Intn(len(s.Vaults))
 	v := s.Vaults[i]
 	if v.Operator == nil {
-		fmt.Println("Warning: tried to allocate safecoin to nil operator")
-		return
+		return ErrNilOperator
 	}
-	v.Operator.AllocateSafecoins(1)
+	s.network.allocateSafecoins(v.Operator, 1)
+	s.network.notifyFarm(v, s)
+	return nil
 }
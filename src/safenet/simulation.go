@@ -0,0 +1,114 @@
+package safenet
+
+// The simulation loops shared by the scripts and the parameter sweep.
+
+// Adds one vault per event for totalEvents events. Once the network has
+// grown past netsize vaults a random vault departs after every join, so the
+// network stays at about netsize vaults while churning.
+// Vaults rejected by the admission policy wait in the join queue and do not
+// count towards netsize. When nothing joins in an event, because the new
+// vault and the queued vaults offered were all rejected, a random vault
+// restarts by departing and rejoining with its age. Without that a policy
+// which waits for vaults to age, such as AgeingSimAdmission, could stop the
+// network churning before it grows to netsize, and no queued vault would
+// ever join.
+func (n *Network) SimulateChurn(netsize, totalEvents int, progress func(float64)) {
+	step := progressStep(totalEvents)
+	for i := 0; i < totalEvents; i++ {
+		// logging
+		if progress != nil && i%step == 0 {
+			progress(float64(i) / float64(totalEvents))
+		}
+		// create new vault
+		joins := n.TotalJoins
+		v := NewVault(n)
+		n.AddVault(v)
+		// keep churning while the join queue is stuck
+		if n.TotalJoins == joins && n.TotalVaults() > 0 {
+			restarting := n.GetRandomVault()
+			n.RemoveVault(restarting)
+			n.AddVault(restarting)
+		}
+		// remove existing vaults, more than one if queued vaults have joined
+		for n.TotalVaults() > netsize {
+			v := n.GetRandomVault()
+			n.RemoveVault(v)
+		}
+	}
+}
+
+// Attacks the network until the attacker owns a section and returns the
+// number of attacking vaults it took. Attacking vaults have random names.
+// progress is called with the attacking vault count every 1000 vaults.
+func (n *Network) GoogleAttack(progress func(int)) int {
+	return n.attack(func() *Vault {
+		return NewVault(n)
+	}, progress)
+}
+
+// Like GoogleAttack but every attacking vault starts with the same random
+// prefix, so they are only ever relocated to the neighbours of that prefix
+// which is a fairly small subsection of the network.
+func (n *Network) TargetedGoogleAttack(progress func(int)) int {
+	attackPrefix := NewXorName(n)
+	// TODO should set prefixBitCount to the current length of the
+	// section prefix length. 64 is a sort-of suitable compromise
+	// which is valid for networks with up to about 2^64 sections
+	prefixBitCount := 64
+	return n.attack(func() *Vault {
+		a := NewVault(n)
+		// set vault to use the attack prefix
+		for i := 0; i < prefixBitCount; i++ {
+			// TODO vault / prefix abstraction seems wrong here, too messy
+			a.Name.SetBit(i, attackPrefix.GetBit(i))
+		}
+		return a
+	}, progress)
+}
+
+func (n *Network) attack(newAttacker func() *Vault, progress func(int)) int {
+	attackVaultCount := 0
+	for true {
+		// logging
+		if progress != nil && attackVaultCount%1000 == 0 {
+			progress(attackVaultCount)
+		}
+		// add an attacking vault, which may have to wait in the join queue
+		a := newAttacker()
+		a.IsAttacker = true
+		n.AddVault(a)
+		attackVaultCount = attackVaultCount + 1
+		// check if attack has worked
+		s := n.GetSectionForXorname(a.Name)
+		if s.IsAttacked() {
+			break
+		}
+		// TODO edge case: if section just split it may have
+		// caused the sibling section to be attacked so
+		// should check the sibling section
+		// add one normal vault for every ten attacking
+		if attackVaultCount%10 == 0 {
+			v := NewVault(n)
+			n.AddVault(v)
+		}
+		// remove a non-attacking vault for every ten attacking
+		if attackVaultCount%10 == 0 {
+			e := n.GetRandomVault()
+			for e.IsAttacker {
+				e = n.GetRandomVault()
+			}
+			n.RemoveVault(e)
+		}
+	}
+	return attackVaultCount
+}
+
+// Reports progress roughly every 0.1% of events, and for small simulations
+// on every event.
+func progressStep(totalEvents int) int {
+	step := totalEvents / 1000
+	if step < 1 {
+		step = 1
+	}
+	return step
+}
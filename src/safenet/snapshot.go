@@ -0,0 +1,446 @@
+package safenet
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// A snapshot stores the complete state of a network so that experiments can
+// start from a saved network instead of rebuilding it, and several variants
+// can fork from the same baseline.
+// Vaults are referred to by their position in the Vaults slice so that
+// sections and operators share the same vault when restored.
+
+// Increased whenever the saved state changes, such as a new random stream,
+// so that older snapshots are rejected instead of restored wrongly.
+// Snapshots without a version are version 0.
+const snapshotVersion = 1
+
+type networkSnapshot struct {
+	Version            int
+	Seed               int64
+	Params             Params
+	Label              string
+	Vaults             []vaultSnapshot
+	LiveVaults         int // the first LiveVaults vaults are in the registry
+	Sections           []sectionSnapshot
+	Clients            []clientSnapshot
+	TotalMerges        int
+	TotalSplits        int
+	TotalJoins         int
+	TotalDepartures    int
+	TotalRelocations   int
+	NeighbourhoodHops  []int
+	VaultSampling      VaultSampling
+	EventHashing       EventHashing
+	TotalSafecoins     int32
+	EventSeq           int
+	Warnings           map[string]int
+	RandomState        []uint64
+	Steps              int
+	JoinQueue          []queuedJoinSnapshot
+	TotalRejections    int
+	MaxJoinQueueLength int
+	JoinWaits          []int
+}
+
+type queuedJoinSnapshot struct {
+	Vault int
+	Since int
+}
+
+type prefixSnapshot struct {
+	Bits   XorName
+	Length uint16
+}
+
+type vaultSnapshot struct {
+	Name       XorName
+	Prefix     prefixSnapshot
+	Age        int
+	ChurnCount int
+	ElderSince int
+	IsAttacker bool
+	Chunks     []XorName
+	TotalMb    int64
+	Operator   int // index of the client operating this vault, or -1
+}
+
+type sectionSnapshot struct {
+	Prefix    prefixSnapshot
+	Vaults    []int
+	Elders    []int
+	Uploaders []string
+}
+
+type clientSnapshot struct {
+	Type       string
+	Id         string
+	Vaults     []int
+	Safecoins  int32
+	PutBalance float64
+	PutHistory []float64
+	GetHistory []float64
+}
+
+// Saves the network to a file, see WriteSnapshot
+func (n *Network) SaveSnapshot(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = n.WriteSnapshot(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Loads a network from a file, see ReadSnapshot
+func LoadSnapshot(filename string) (*Network, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSnapshot(f)
+}
+
+// Writes sections, vaults, clients, the join queue, counters and the state of
+// the random streams. Only the client types in this package can be saved.
+// The admission policy, relocation strategy and relocation trigger are not
+// saved and must be set again after loading. Neither are CostModel and
+// Debug, so a loaded network has the default cost model and Debug off.
+func (n *Network) WriteSnapshot(w io.Writer) error {
+	s := networkSnapshot{
+		Version:            snapshotVersion,
+		Seed:               n.seed,
+		Params:             n.Params,
+		Label:              n.Label,
+		TotalMerges:        n.TotalMerges,
+		TotalSplits:        n.TotalSplits,
+		TotalJoins:         n.TotalJoins,
+		TotalDepartures:    n.TotalDepartures,
+		TotalRelocations:   n.TotalRelocations,
+		NeighbourhoodHops:  n.NeighbourhoodHops,
+		VaultSampling:      n.VaultSampling,
+		EventHashing:       n.EventHashing,
+		TotalSafecoins:     n.totalSafecoins,
+		EventSeq:           n.eventSeq,
+		Warnings:           n.warnings,
+		RandomState:        n.rng.state(),
+		Steps:              n.steps,
+		TotalRejections:    n.TotalRejections,
+		MaxJoinQueueLength: n.MaxJoinQueueLength,
+		JoinWaits:          n.JoinWaits,
+	}
+	// index vaults, starting with the registry so its order is kept
+	vaultIndexes := map[*Vault]int{}
+	vaults := []*Vault{}
+	indexVault := func(v *Vault) int {
+		i, exists := vaultIndexes[v]
+		if !exists {
+			i = len(vaults)
+			vaultIndexes[v] = i
+			vaults = append(vaults, v)
+		}
+		return i
+	}
+	for _, v := range n.vaults.vaults {
+		indexVault(v)
+	}
+	s.LiveVaults = len(vaults)
+	// sections
+	for _, section := range n.sections.all() {
+		ss := sectionSnapshot{
+			Prefix:    newPrefixSnapshot(section.Prefix),
+			Vaults:    []int{},
+			Elders:    []int{},
+			Uploaders: []string{},
+		}
+		for _, v := range section.Vaults {
+			ss.Vaults = append(ss.Vaults, indexVault(v))
+		}
+		for _, v := range section.elderSet {
+			ss.Elders = append(ss.Elders, indexVault(v))
+		}
+		for id := range section.Uploaders {
+			ss.Uploaders = append(ss.Uploaders, id)
+		}
+		sort.Strings(ss.Uploaders)
+		s.Sections = append(s.Sections, ss)
+	}
+	// join queue
+	for _, q := range n.joinQueue {
+		s.JoinQueue = append(s.JoinQueue, queuedJoinSnapshot{indexVault(q.vault), q.since})
+	}
+	// clients
+	operatorIndexes := map[Operator]int{}
+	for i, c := range n.Clients {
+		cs, o, err := newClientSnapshot(c)
+		if err != nil {
+			return err
+		}
+		for _, v := range o.Vaults {
+			cs.Vaults = append(cs.Vaults, indexVault(v))
+		}
+		s.Clients = append(s.Clients, cs)
+		operatorIndexes[c] = i
+		operatorIndexes[clientOperator(c)] = i
+	}
+	// vaults
+	for _, v := range vaults {
+		operator := -1
+		if v.Operator != nil {
+			i, exists := operatorIndexes[v.Operator]
+			if !exists {
+				return fmt.Errorf("vault operator is not a client of the network")
+			}
+			operator = i
+		}
+		s.Vaults = append(s.Vaults, vaultSnapshot{
+			Name:       v.Name,
+			Prefix:     newPrefixSnapshot(v.Prefix),
+			Age:        v.Age,
+			ChurnCount: v.ChurnCount,
+			ElderSince: v.elderSince,
+			IsAttacker: v.IsAttacker,
+			Chunks:     v.Chunks,
+			TotalMb:    v.TotalMb,
+			Operator:   operator,
+		})
+	}
+	return gob.NewEncoder(w).Encode(s)
+}
+
+// Reads a network written by WriteSnapshot. The restored network continues
+// exactly as the saved network would have. Snapshots in an older format are
+// rejected.
+func ReadSnapshot(r io.Reader) (*Network, error) {
+	s := networkSnapshot{}
+	err := gob.NewDecoder(r).Decode(&s)
+	if err != nil {
+		return nil, err
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("snapshot has format version %d, expected %d, rebuild it", s.Version, snapshotVersion)
+	}
+	n := NewNetworkFromSeed(s.Seed, s.Params)
+	n.Label = s.Label
+	if len(s.RandomState) != len(n.rng.sources) {
+		return nil, fmt.Errorf("snapshot has %d random streams, expected %d", len(s.RandomState), len(n.rng.sources))
+	}
+	n.TotalMerges = s.TotalMerges
+	n.TotalSplits = s.TotalSplits
+	n.TotalJoins = s.TotalJoins
+	n.TotalDepartures = s.TotalDepartures
+	n.TotalRelocations = s.TotalRelocations
+	n.NeighbourhoodHops = s.NeighbourhoodHops
+	if n.NeighbourhoodHops == nil {
+		n.NeighbourhoodHops = []int{}
+	}
+	n.VaultSampling = s.VaultSampling
+	n.EventHashing = s.EventHashing
+	n.totalSafecoins = s.TotalSafecoins
+	n.eventSeq = s.EventSeq
+	n.warnings = s.Warnings
+	n.steps = s.Steps
+	n.TotalRejections = s.TotalRejections
+	n.MaxJoinQueueLength = s.MaxJoinQueueLength
+	n.JoinWaits = s.JoinWaits
+	// vaults
+	vaults := make([]*Vault, len(s.Vaults))
+	for i, vs := range s.Vaults {
+		v := &Vault{
+			Name:          vs.Name,
+			Prefix:        vs.Prefix.prefix(),
+			Age:           vs.Age,
+			ChurnCount:    vs.ChurnCount,
+			elderSince:    vs.ElderSince,
+			IsAttacker:    vs.IsAttacker,
+			Chunks:        vs.Chunks,
+			TotalMb:       vs.TotalMb,
+			registryIndex: -1,
+			network:       n,
+		}
+		if v.Chunks == nil {
+			v.Chunks = []XorName{}
+		}
+		vaults[i] = v
+		if i < s.LiveVaults {
+			n.vaults.add(v)
+		}
+	}
+	getVault := func(i int) (*Vault, error) {
+		if i < 0 || i >= len(vaults) {
+			return nil, fmt.Errorf("snapshot refers to vault %d of %d", i, len(vaults))
+		}
+		return vaults[i], nil
+	}
+	// sections
+	for _, ss := range s.Sections {
+		section := &Section{
+			Prefix:    ss.Prefix.prefix(),
+			Vaults:    []*Vault{},
+			Uploaders: map[string]bool{},
+			network:   n,
+		}
+		for _, i := range ss.Vaults {
+			v, err := getVault(i)
+			if err != nil {
+				return nil, err
+			}
+			section.appendVault(v)
+		}
+		for _, i := range ss.Elders {
+			v, err := getVault(i)
+			if err != nil {
+				return nil, err
+			}
+			v.elder = true
+			section.elderSet = append(section.elderSet, v)
+		}
+		for _, id := range ss.Uploaders {
+			section.Uploaders[id] = true
+		}
+		n.sections.insert(section)
+	}
+	// join queue
+	for _, qs := range s.JoinQueue {
+		v, err := getVault(qs.Vault)
+		if err != nil {
+			return nil, err
+		}
+		n.joinQueue = append(n.joinQueue, queuedJoin{v, qs.Since})
+	}
+	// clients
+	operators := []Operator{}
+	for _, cs := range s.Clients {
+		c, o, err := cs.client(n)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range cs.Vaults {
+			v, err := getVault(i)
+			if err != nil {
+				return nil, err
+			}
+			o.Vaults = append(o.Vaults, v)
+		}
+		// append rather than calling AddClient, which would count the
+		// coins again
+		n.Clients = append(n.Clients, c)
+		operators = append(operators, clientOperator(c))
+	}
+	// vault operators
+	for i, vs := range s.Vaults {
+		if vs.Operator < 0 {
+			continue
+		}
+		if vs.Operator >= len(operators) {
+			return nil, fmt.Errorf("snapshot refers to client %d of %d", vs.Operator, len(operators))
+		}
+		vaults[i].Operator = operators[vs.Operator]
+	}
+	// restore random streams last since creating clients draws from them
+	n.rng.setState(s.RandomState)
+	return n, nil
+}
+
+func newPrefixSnapshot(p Prefix) prefixSnapshot {
+	return prefixSnapshot{
+		Bits:   p.bits,
+		Length: p.length,
+	}
+}
+
+func (ps prefixSnapshot) prefix() Prefix {
+	return Prefix{
+		bits:   ps.Bits,
+		length: ps.Length,
+	}
+}
+
+// Returns the operator the client gives to its vaults. This is the embedded
+// operator rather than the client itself.
+func clientOperator(c Client) Operator {
+	switch t := c.(type) {
+	case *ConsistentClient:
+		return &t.ConsistentOperator
+	case *InconsistentClient:
+		return &t.InconsistentOperator
+	case *HolderClient:
+		return &t.HolderOperator
+	case *TemplateClient:
+		return &t.TemplateOperator
+	}
+	return c
+}
+
+func newClientSnapshot(c Client) (clientSnapshot, *UniversalOperator, error) {
+	cs := clientSnapshot{
+		Vaults: []int{},
+	}
+	var o *UniversalOperator
+	switch t := c.(type) {
+	case *ConsistentClient:
+		cs.Type = "consistent"
+		cs.Id = t.ConsistentUploader.IdStr
+		o = &t.ConsistentOperator.UniversalOperator
+	case *InconsistentClient:
+		cs.Type = "inconsistent"
+		cs.Id = t.InconsistentUploader.IdStr
+		cs.PutHistory = t.InconsistentUploader.PutHistory
+		cs.GetHistory = t.InconsistentDownloader.GetHistory
+		o = &t.InconsistentOperator.UniversalOperator
+	case *HolderClient:
+		cs.Type = "holder"
+		cs.Id = t.HolderUploader.IdStr
+		o = &t.HolderOperator.UniversalOperator
+	case *TemplateClient:
+		cs.Type = "template"
+		cs.Id = t.TemplateUploader.IdStr
+		o = &t.TemplateOperator.UniversalOperator
+	default:
+		return cs, nil, fmt.Errorf("cannot snapshot client of type %T", c)
+	}
+	cs.Safecoins = o.Safecoins
+	cs.PutBalance = o.PutBalance
+	return cs, o, nil
+}
+
+// Creates the client described by the snapshot, returning the client and its
+// operator so vaults can be added to it.
+func (cs clientSnapshot) client(n *Network) (Client, *UniversalOperator, error) {
+	var c Client
+	var o *UniversalOperator
+	switch cs.Type {
+	case "consistent":
+		t := NewConsistentClient(n)
+		t.ConsistentUploader.IdStr = cs.Id
+		c, o = t, &t.ConsistentOperator.UniversalOperator
+	case "inconsistent":
+		t := NewInconsistentClient(n)
+		t.InconsistentUploader.IdStr = cs.Id
+		t.InconsistentUploader.PutHistory = append(t.InconsistentUploader.PutHistory, cs.PutHistory...)
+		t.InconsistentDownloader.GetHistory = append(t.InconsistentDownloader.GetHistory, cs.GetHistory...)
+		c, o = t, &t.InconsistentOperator.UniversalOperator
+	case "holder":
+		t := NewHolderClient(n)
+		t.HolderUploader.IdStr = cs.Id
+		c, o = t, &t.HolderOperator.UniversalOperator
+	case "template":
+		t := NewTemplateClient(n)
+		t.TemplateUploader.IdStr = cs.Id
+		c, o = t, &t.TemplateOperator.UniversalOperator
+	default:
+		return nil, nil, fmt.Errorf("unknown client type %s in snapshot", cs.Type)
+	}
+	o.Safecoins = cs.Safecoins
+	o.PutBalance = cs.PutBalance
+	return c, o, nil
+}
@@ -0,0 +1,99 @@
+package safenet
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+func buildTestNetwork(seed int64, vaults int) *Network {
+	n := NewNetworkFromSeed(seed, DefaultParams())
+	c := NewConsistentClient(n)
+	c.AllocateSafecoins(10)
+	n.AddClient(c)
+	for i := 0; i < vaults; i++ {
+		n.AddVault(NewVault(n))
+		if i%10 == 0 {
+			for _, v := range c.NewVaultsToStart() {
+				n.AddVault(v)
+			}
+		}
+		if i%3 == 0 {
+			n.RemoveVault(n.GetRandomVault())
+		}
+	}
+	return n
+}
+
+func TestSnapshotRestoresIdenticalNetwork(t *testing.T) {
+	n := buildTestNetwork(1, 300)
+	n.Label = "300 vaults"
+	b := bytes.Buffer{}
+	err := n.WriteSnapshot(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadSnapshot(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.TotalVaults() != n.TotalVaults() || m.TotalSections() != n.TotalSections() {
+		t.Error("restored network has different size")
+	}
+	if m.Seed() != 1 || m.Params.GroupSize != n.Params.GroupSize || m.Label != n.Label {
+		t.Error("restored network has a different seed, params or label")
+	}
+	// operators are shared between the client and its vaults
+	c := m.Clients[0].(*ConsistentClient)
+	for _, v := range c.ConsistentOperator.Vaults {
+		if v.Operator != &c.ConsistentOperator {
+			t.Error("restored vault has wrong operator")
+		}
+	}
+	// both networks continue identically
+	for i := 0; i < 200; i++ {
+		n.AddVault(NewVault(n))
+		m.AddVault(NewVault(m))
+		nv := n.GetRandomVault()
+		mv := m.GetRandomVault()
+		if nv.Name != mv.Name {
+			t.Fatal("restored network diverged after", i, "events")
+		}
+		n.RemoveVault(nv)
+		m.RemoveVault(mv)
+	}
+	if m.TotalRelocations != n.TotalRelocations || m.TotalSplits != n.TotalSplits {
+		t.Error("restored network has different totals")
+	}
+}
+
+func TestSnapshotRejectsOldFormat(t *testing.T) {
+	// snapshots from before versioning decode as version 0
+	b := bytes.Buffer{}
+	err := gob.NewEncoder(&b).Encode(networkSnapshot{Params: DefaultParams()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadSnapshot(&b)
+	if err == nil {
+		t.Error("Expected an error for an old snapshot")
+	}
+}
+
+func TestSnapshotResetsOptions(t *testing.T) {
+	n := buildTestNetwork(1, 50)
+	n.CostModel.MessageBytes = 1
+	n.Debug = true
+	b := bytes.Buffer{}
+	err := n.WriteSnapshot(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadSnapshot(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.CostModel != DefaultCostModel() || m.Debug {
+		t.Errorf("Restored cost model %+v and debug %t", m.CostModel, m.Debug)
+	}
+}
@@ -0,0 +1,276 @@
+package safenet
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// A parameter sweep runs the churn simulation and the google attack for every
+// combination of parameter values, optionally over several seeds, and writes
+// one CSV row per combination.
+
+// The values to sweep for one parameter. In json it can be a single number,
+// a list of numbers, or a range such as {"from": 6, "to": 12, "step": 2}
+// where step defaults to 1.
+type SweepRange []int
+
+func (r *SweepRange) UnmarshalJSON(data []byte) error {
+	var value int
+	if err := json.Unmarshal(data, &value); err == nil {
+		*r = SweepRange{value}
+		return nil
+	}
+	var values []int
+	if err := json.Unmarshal(data, &values); err == nil {
+		*r = SweepRange(values)
+		return nil
+	}
+	var span struct {
+		From int `json:"from"`
+		To   int `json:"to"`
+		Step int `json:"step"`
+	}
+	if err := json.Unmarshal(data, &span); err != nil {
+		return fmt.Errorf("sweep range must be a number, list or {from, to, step}: %s", data)
+	}
+	if span.Step == 0 {
+		span.Step = 1
+	}
+	if span.Step < 0 || span.To < span.From {
+		return fmt.Errorf("sweep range must go from low to high: %s", data)
+	}
+	values = []int{}
+	for v := span.From; v <= span.To; v = v + span.Step {
+		values = append(values, v)
+	}
+	*r = SweepRange(values)
+	return nil
+}
+
+type SweepConfig struct {
+	// the google attack is slow for large networks so can be skipped
+	SkipAttack bool `json:"skip_attack" usage:"leave out the google attack"`
+	// not swept, the same trigger is used for every combination
+	RelocationTrigger string     `json:"relocation_trigger" usage:"which vault is relocated after each event: hash-age, xor-tiebreak, churn-counter or off"`
+	Netsize           SweepRange `json:"netsize"`
+	GroupSize         SweepRange `json:"group_size"`
+	SplitBuffer       SweepRange `json:"split_buffer"`
+	QuorumNumerator   SweepRange `json:"quorum_numerator"`
+	QuorumDenominator SweepRange `json:"quorum_denominator"`
+	AdultAge          SweepRange `json:"adult_age"`
+}
+
+// Sweeps nothing, using the default params and a network of 100000 vaults.
+func DefaultSweepConfig() SweepConfig {
+	p := DefaultParams()
+	return SweepConfig{
+		RelocationTrigger: HashAgeTriggerName,
+		Netsize:           SweepRange{100000},
+		GroupSize:         SweepRange{p.GroupSize},
+		SplitBuffer:       SweepRange{p.SplitBuffer},
+		QuorumNumerator:   SweepRange{p.QuorumNumerator},
+		QuorumDenominator: SweepRange{p.QuorumDenominator},
+		AdultAge:          SweepRange{p.AdultAge},
+	}
+}
+
+// One combination of swept values
+type SweepPoint struct {
+	Netsize int
+	Params  Params
+}
+
+// Every combination of the swept values, varying the last parameter fastest.
+func (c SweepConfig) Points() []SweepPoint {
+	points := []SweepPoint{}
+	for _, netsize := range c.Netsize {
+		for _, groupSize := range c.GroupSize {
+			for _, splitBuffer := range c.SplitBuffer {
+				for _, numerator := range c.QuorumNumerator {
+					for _, denominator := range c.QuorumDenominator {
+						for _, adultAge := range c.AdultAge {
+							p := DefaultParams()
+							p.GroupSize = groupSize
+							p.SplitBuffer = splitBuffer
+							p.QuorumNumerator = numerator
+							p.QuorumDenominator = denominator
+							p.AdultAge = adultAge
+							points = append(points, SweepPoint{netsize, p})
+						}
+					}
+				}
+			}
+		}
+	}
+	return points
+}
+
+var sweepColumns = []string{
+	"netsize",
+	"group_size",
+	"split_buffer",
+	"quorum_numerator",
+	"quorum_denominator",
+	"adult_age",
+	"seeds",
+	"section_size_mean",
+	"section_size_variance",
+	"section_size_skewness",
+	"section_size_kurtosis",
+	"splits_mean",
+	"splits_stddev",
+	"merges_mean",
+	"merges_stddev",
+	"relocation_hops_mean",
+	"relocation_hops_stddev",
+	"attack_vaults_mean",
+	"attack_vaults_stddev",
+	"warnings_mean",
+}
+
+// Runs every combination for seeds consecutive seeds from firstSeed
+// concurrently and writes the results to w as CSV, one row per combination.
+// Progress is written to progress, which may be nil.
+func RunSweep(c SweepConfig, firstSeed int64, seeds int, w io.Writer, progress io.Writer) error {
+	_, err := NewRelocationTrigger(c.RelocationTrigger)
+	if err != nil {
+		return err
+	}
+	points := c.Points()
+	for _, point := range points {
+		err = point.Params.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	if seeds < 1 {
+		seeds = 1
+	}
+	if progress != nil {
+		fmt.Fprintln(progress, len(points), "combinations of", seeds, "seeds")
+	}
+	results := make([]*SeedResult, len(points)*seeds)
+	runJobs(len(results), progress, func(i int, progress func(float64)) {
+		point := points[i/seeds]
+		seed := firstSeed + int64(i%seeds)
+		results[i] = simulateSweepPoint(point, seed, c, progress)
+	})
+	out := csv.NewWriter(w)
+	out.Write(sweepColumns)
+	for i, point := range points {
+		pointResults := results[i*seeds : (i+1)*seeds]
+		sizes := Histogram{}
+		for _, h := range HistogramsNamed(pointResults, "size") {
+			for k, count := range h {
+				sizes[k] = sizes[k] + count
+			}
+		}
+		m := sizes.Moments()
+		splits := Summarise(TotalsNamed(pointResults, "total splits"))
+		merges := Summarise(TotalsNamed(pointResults, "total merges"))
+		hops := Summarise(TotalsNamed(pointResults, "mean hops"))
+		attack := Summarise(TotalsNamed(pointResults, "attack vaults"))
+		warnings := Summarise(TotalsNamed(pointResults, warningsTotal))
+		row := []string{
+			strconv.Itoa(point.Netsize),
+			strconv.Itoa(point.Params.GroupSize),
+			strconv.Itoa(point.Params.SplitBuffer),
+			strconv.Itoa(point.Params.QuorumNumerator),
+			strconv.Itoa(point.Params.QuorumDenominator),
+			strconv.Itoa(point.Params.AdultAge),
+			strconv.Itoa(seeds),
+			formatFloat(m.Mean),
+			formatFloat(m.Variance),
+			formatFloat(m.Skewness),
+			formatFloat(m.Kurtosis),
+			formatFloat(splits.Mean),
+			formatFloat(splits.StdDev),
+			formatFloat(merges.Mean),
+			formatFloat(merges.StdDev),
+			formatFloat(hops.Mean),
+			formatFloat(hops.StdDev),
+		}
+		if c.SkipAttack {
+			row = append(row, "", "")
+		} else {
+			row = append(row, formatFloat(attack.Mean), formatFloat(attack.StdDev))
+		}
+		row = append(row, formatFloat(warnings.Mean))
+		out.Write(row)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// The same churn as the section size distribution, followed by the google
+// attack on the resulting network.
+func simulateSweepPoint(point SweepPoint, seed int64, c SweepConfig, progress func(float64)) *SeedResult {
+	network := NewNetworkFromSeed(seed, point.Params)
+	network.RelocationTrigger, _ = NewRelocationTrigger(c.RelocationTrigger)
+	network.SimulateChurn(point.Netsize, point.Netsize*5, progress)
+	result := NewSeedResult(seed)
+	sizes := result.Histogram("size")
+	for _, s := range network.Sections() {
+		sizes.Add(len(s.Vaults))
+	}
+	result.Totals["total splits"] = float64(network.TotalSplits)
+	result.Totals["total merges"] = float64(network.TotalMerges)
+	totalHops := 0
+	for _, h := range network.NeighbourhoodHops {
+		totalHops = totalHops + h
+	}
+	if len(network.NeighbourhoodHops) > 0 {
+		result.Totals["mean hops"] = float64(totalHops) / float64(len(network.NeighbourhoodHops))
+	}
+	if !c.SkipAttack {
+		result.Totals["attack vaults"] = float64(network.GoogleAttack(nil))
+	}
+	result.AddWarnings(network)
+	return result
+}
+
+// Population moments of the histogram keys weighted by their counts.
+// Kurtosis is excess kurtosis, so zero for a normal distribution.
+type Moments struct {
+	Mean     float64
+	Variance float64
+	Skewness float64
+	Kurtosis float64
+}
+
+func (h Histogram) Moments() Moments {
+	m := Moments{}
+	total := 0
+	for k, count := range h {
+		m.Mean = m.Mean + float64(k*count)
+		total = total + count
+	}
+	if total == 0 {
+		return m
+	}
+	m.Mean = m.Mean / float64(total)
+	m3 := 0.0
+	m4 := 0.0
+	for k, count := range h {
+		d := float64(k) - m.Mean
+		m.Variance = m.Variance + d*d*float64(count)
+		m3 = m3 + d*d*d*float64(count)
+		m4 = m4 + d*d*d*d*float64(count)
+	}
+	m.Variance = m.Variance / float64(total)
+	m3 = m3 / float64(total)
+	m4 = m4 / float64(total)
+	if m.Variance > 0 {
+		m.Skewness = m3 / math.Pow(m.Variance, 1.5)
+		m.Kurtosis = m4/(m.Variance*m.Variance) - 3
+	}
+	return m
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 6, 64)
+}
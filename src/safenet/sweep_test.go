@@ -0,0 +1,57 @@
+package safenet
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSweepRangeForms(t *testing.T) {
+	c := SweepConfig{}
+	config := `{
+		"netsize": 1000,
+		"group_size": {"from": 6, "to": 12, "step": 2},
+		"split_buffer": {"from": 1, "to": 3},
+		"adult_age": [4, 6]
+	}`
+	err := json.Unmarshal([]byte(config), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Netsize) != 1 || c.Netsize[0] != 1000 {
+		t.Errorf("Single value range is %v", c.Netsize)
+	}
+	if len(c.GroupSize) != 4 || c.GroupSize[0] != 6 || c.GroupSize[3] != 12 {
+		t.Errorf("Stepped range is %v", c.GroupSize)
+	}
+	if len(c.SplitBuffer) != 3 {
+		t.Errorf("Default step range is %v", c.SplitBuffer)
+	}
+	c.QuorumNumerator = SweepRange{1}
+	c.QuorumDenominator = SweepRange{2}
+	points := c.Points()
+	if len(points) != 1*4*3*2 {
+		t.Errorf("Sweep has %d points", len(points))
+	}
+	last := points[len(points)-1]
+	if last.Params.GroupSize != 12 || last.Params.SplitBuffer != 3 || last.Params.AdultAge != 6 {
+		t.Errorf("Last point is %+v", last)
+	}
+}
+
+func TestSweepRangeRejectsBackwards(t *testing.T) {
+	r := SweepRange{}
+	err := json.Unmarshal([]byte(`{"from": 12, "to": 6}`), &r)
+	if err == nil {
+		t.Error("Backwards range should be an error")
+	}
+}
+
+func TestHistogramMoments(t *testing.T) {
+	h := Histogram{}
+	h.Add(1)
+	h.Add(3)
+	m := h.Moments()
+	if m.Mean != 2 || m.Variance != 1 || m.Skewness != 0 || m.Kurtosis != -2 {
+		t.Errorf("Moments are %+v", m)
+	}
+}
@@ -21,7 +21,7 @@ func (o *TemplateOperator) NewVaultsToStart() []*Vault {
 	// by whatever script is creating this client.
 	newVaults := []*Vault{}
 	for i := 0; i < totalNewVaults; i++ {
-		v := NewVaultForOperator(o)
+		v := NewVaultForOperator(o.network, o)
 		newVaults = append(newVaults, v)
 		o.Vaults = append(o.Vaults, v)
 	}
@@ -47,7 +47,7 @@ func (o *TemplateOperator) ExistingVaultsToStop() []*Vault {
 	return toStop
 }
 
-// Don't forget to update client.go:NewRandomClient() with this new client type!
+// Don't forget to update client.go:NewRandomClient(n) with this new client type!
 
 // The rest is for setting up. Some parts may want to be changed but usually it
 // can stay how it is.
@@ -58,12 +58,11 @@ type TemplateClient struct {
 	TemplateOperator
 }
 
-func NewTemplateClient() *TemplateClient {
+func NewTemplateClient(n *Network) *TemplateClient {
 	c := TemplateClient{}
-	idBytes := make([]byte, 8)
-	prng.Read(idBytes)
-	c.TemplateUploader.IdStr = string(idBytes)
+	c.TemplateUploader.IdStr = newClientId(n)
 	c.TemplateOperator.Vaults = []*Vault{}
+	c.TemplateOperator.network = n
 	return &c
 }
 
@@ -0,0 +1,134 @@
+package safenet
+
+import (
+	"fmt"
+)
+
+// Chooses the vault to relocate after an event in a section, or nil for no
+// relocation. It is called once for every join, departure and merge in the
+// section. Relocated vaults age by one.
+type RelocationTrigger interface {
+	VaultToRelocate(s *Section, ne *NetworkEvent) *Vault
+}
+
+// Names of the relocation triggers for NewRelocationTrigger
+const (
+	HashAgeTriggerName      = "hash-age"
+	XorTiebreakTriggerName  = "xor-tiebreak"
+	ChurnCounterTriggerName = "churn-counter"
+	NoRelocationTriggerName = "off"
+)
+
+func NewRelocationTrigger(name string) (RelocationTrigger, error) {
+	switch name {
+	case "", HashAgeTriggerName:
+		return HashAgeTrigger{}, nil
+	case XorTiebreakTriggerName:
+		return XorTiebreakTrigger{}, nil
+	case ChurnCounterTriggerName:
+		return ChurnCounterTrigger{}, nil
+	case NoRelocationTriggerName:
+		return NoRelocationTrigger{}, nil
+	}
+	return nil, fmt.Errorf("unknown relocation trigger %s, use %s, %s, %s or %s", name, HashAgeTriggerName, XorTiebreakTriggerName, ChurnCounterTriggerName, NoRelocationTriggerName)
+}
+
+// Relocates the oldest vault for which the event hash H % 2^age == 0, with
+// ties going to the vault closest to H. This is the default when
+// Network.RelocationTrigger is nil.
+type HashAgeTrigger struct{}
+
+func (t HashAgeTrigger) VaultToRelocate(s *Section, ne *NetworkEvent) *Vault {
+	// find vault to relocate based on a randomly generated 'event hash'
+	// see https://forum.safedev.org/t/data-chains-deeper-dive/1209
+	// As we receive/form a valid block of Live for non-infant peers, we take
+	// the Hash of the event H. Then if H % 2^age == 0 for any peer (sorted by
+	// age ascending) in our section, we relocate this node to the neighbour
+	// that has the lowest number of peers.
+	// H % 2^age == 0 for every age up to the trailing zeros of H, so the due
+	// vaults are the oldest of those.
+	due := s.byAge.agedAtMost(ne.hash.TrailingZeros()).sameAge()
+	var v *Vault
+	var smallestTiebreaker XorName
+	for i, w := range due {
+		// tiebreaker
+		// If there are multiple peers of the same age then XOR their
+		// public keys together and find the one XOR closest to it.
+		// This only compares each key with H, XorTiebreakTrigger
+		// XORs all keys of this age.
+		xordistance := w.Name.Xor(ne.hash)
+		if i == 0 || xordistance.IsLessThan(smallestTiebreaker) {
+			smallestTiebreaker = xordistance
+			v = w
+		}
+	}
+	return v
+}
+
+// The same as HashAgeTrigger, but when several vaults of the oldest age are
+// due their names are XORed together and the vault closest to the result is
+// relocated.
+type XorTiebreakTrigger struct{}
+
+func (t XorTiebreakTrigger) VaultToRelocate(s *Section, ne *NetworkEvent) *Vault {
+	due := s.byAge.agedAtMost(ne.hash.TrailingZeros()).sameAge()
+	return xorTiebreak(due)
+}
+
+// The RFC0045 node ageing model, every vault counts the events in its
+// section and is due for relocation once the count reaches 2^age. The oldest
+// due vault is relocated, with ties broken as in XorTiebreakTrigger, and its
+// count starts again from zero.
+type ChurnCounterTrigger struct{}
+
+func (t ChurnCounterTrigger) VaultToRelocate(s *Section, ne *NetworkEvent) *Vault {
+	due := []*Vault{}
+	for _, w := range s.Vaults {
+		w.ChurnCount = w.ChurnCount + 1
+		if w.ChurnCount >= 1<<uint(w.Age) {
+			due = append(due, w)
+		}
+	}
+	return xorTiebreak(oldestVaults(due))
+}
+
+// Never relocates, so vaults never age.
+type NoRelocationTrigger struct{}
+
+func (t NoRelocationTrigger) VaultToRelocate(s *Section, ne *NetworkEvent) *Vault {
+	return nil
+}
+
+// Returns the vaults with the highest age
+func oldestVaults(vaults []*Vault) []*Vault {
+	oldest := []*Vault{}
+	for _, v := range vaults {
+		if len(oldest) > 0 && v.Age < oldest[0].Age {
+			continue
+		}
+		if len(oldest) > 0 && v.Age > oldest[0].Age {
+			oldest = []*Vault{}
+		}
+		oldest = append(oldest, v)
+	}
+	return oldest
+}
+
+// Returns the vault closest to the XOR of all the vault names, or nil if
+// there are no vaults.
+func xorTiebreak(vaults []*Vault) *Vault {
+	if len(vaults) == 0 {
+		return nil
+	}
+	var key XorName
+	for _, v := range vaults {
+		key = key.Xor(v.Name)
+	}
+	closest := vaults[0]
+	for _, v := range vaults[1:] {
+		if v.Name.IsCloserTo(key, closest.Name) {
+			closest = v
+		}
+	}
+	return closest
+}
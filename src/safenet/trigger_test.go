@@ -0,0 +1,56 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestXorTiebreakUsesAllNames(t *testing.T) {
+	n := NewNetwork(DefaultParams())
+	a := &Vault{Name: XorName{0x80}}
+	b := &Vault{Name: XorName{0x40}}
+	c := &Vault{Name: XorName{0x20}}
+	// the xor of all names is 0xE0, closest to a
+	if xorTiebreak([]*Vault{c, b, a}) != a {
+		t.Error("Expected the vault closest to the xor of all names")
+	}
+	if xorTiebreak([]*Vault{}) != nil {
+		t.Error("Expected no vault")
+	}
+	a.Age = 2
+	s := &Section{network: n}
+	for _, v := range []*Vault{a, b, c} {
+		s.appendVault(v)
+	}
+	ne := &NetworkEvent{}
+	// a zero hash makes every vault due, so the oldest is chosen
+	if (XorTiebreakTrigger{}).VaultToRelocate(s, ne) != a {
+		t.Error("Expected the oldest vault")
+	}
+}
+
+func TestChurnCounterTrigger(t *testing.T) {
+	n := NewNetwork(DefaultParams())
+	young := NewVault(n)
+	old := NewVault(n)
+	old.Age = 2
+	s := &Section{network: n}
+	s.appendVault(young)
+	s.appendVault(old)
+	ne := NewNetworkEvent(n)
+	tr := ChurnCounterTrigger{}
+	if tr.VaultToRelocate(s, ne) != nil {
+		t.Error("Relocated after one event")
+	}
+	// the young vault is due after 2 events, the old one after 4
+	if tr.VaultToRelocate(s, ne) != young {
+		t.Error("Expected the young vault after two events")
+	}
+	young.ChurnCount = 0
+	tr.VaultToRelocate(s, ne)
+	if tr.VaultToRelocate(s, ne) != old {
+		t.Error("Expected the old vault after four events")
+	}
+	if (NoRelocationTrigger{}).VaultToRelocate(s, ne) != nil {
+		t.Error("Relocation is off")
+	}
+}
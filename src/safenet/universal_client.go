@@ -14,6 +14,7 @@ type UniversalOperator struct {
 	Vaults     []*Vault
 	Safecoins  int32
 	PutBalance float64
+	network    *Network
 }
 
 func (o *UniversalOperator) ConvertCoinsToPutBalance(currentDay int, u Uploader, n *Network) {
@@ -1,19 +1,5 @@
 package safenet
 
-import (
-	"fmt"
-	"math/big"
-)
-
-// the starting storage space for a vault is chosen randomly from this list
-var startingStorageSizesMb = []int64{
-	100,
-	200,
-	300,
-	400,
-	500,
-}
-
 type Vault struct {
 	Name       XorName
 	Prefix     Prefix
@@ -22,43 +8,57 @@ type Vault struct {
 	Chunks     []XorName
 	TotalMb    int64
 	Operator   Operator
+	// events in the vault's section since it was last relocated, used by
+	// ChurnCounterTrigger
+	ChurnCount int
+	// whether the vault is an elder of its section and the step it became one
+	elder      bool
+	elderSince int
+	// position in the network vault registry
+	registryIndex int
+	network       *Network
 }
 
-func NewVault() *Vault {
+func NewVault(n *Network) *Vault {
 	return &Vault{
-		Name:    NewXorName(),
-		Age:     1,
-		TotalMb: 0,
-		Chunks:  []XorName{},
+		Name:          NewXorName(n),
+		Age:           1,
+		TotalMb:       0,
+		Chunks:        []XorName{},
+		registryIndex: -1,
+		network:       n,
 	}
 }
 
-func NewVaultForOperator(o Operator) *Vault {
+func NewVaultForOperator(n *Network, o Operator) *Vault {
 	return &Vault{
-		Name:     NewXorName(),
-		Age:      1,
-		TotalMb:  randomStorageSize(),
-		Chunks:   []XorName{},
-		Operator: o,
+		Name:          NewXorName(n),
+		Age:           1,
+		TotalMb:       randomStorageSize(n),
+		Chunks:        []XorName{},
+		Operator:      o,
+		registryIndex: -1,
+		network:       n,
 	}
 }
 
-func (v *Vault) SetPrefix(p Prefix) {
+// Returns ErrPrefixMismatch if the prefix doesn't match the vault name, in
+// which case the prefix is not changed. Consider if using
+// vault.renameWithPrefix is suitable.
+func (v *Vault) SetPrefix(p Prefix) error {
 	// check new prefix
 	if !p.Matches(v.Name) {
-		fmt.Println("Warning: Tried to set prefix that doesn't match vault name")
-		fmt.Println("This vault will not have their prefix changed")
-		fmt.Println("Consider if using vault.renameWithPrefix is suitable")
-		return
+		return ErrPrefixMismatch
 	}
 	// if the new prefix is longer, some chunks will be dead
-	hasDeadChunks := len(p.bits) > len(v.Prefix.bits)
+	hasDeadChunks := p.Len() > v.Prefix.Len()
 	// set new prefix
 	v.Prefix = p
 	// remove dead chunks
 	if hasDeadChunks {
 		v.removeDeadChunks()
 	}
+	return nil
 }
 
 func (v *Vault) IncrementAge() {
@@ -66,13 +66,15 @@ func (v *Vault) IncrementAge() {
 }
 
 func (v *Vault) IsAdult() bool {
-	return v.Age > 4
+	return v.Age > v.network.Params.AdultAge
 }
 
-func (v *Vault) renameWithPrefix(p Prefix) {
-	v.Name = NewXorName()
-	for i, prefixBit := range p.bits {
-		v.Name.SetBit(i, prefixBit)
+// Relocated names come from the relocation stream so that relocations do not
+// change the names of vaults created later.
+func (v *Vault) renameWithPrefix(n *Network, p Prefix) {
+	v.Name = newXorName(n.rng.relocation)
+	for i := 0; i < p.Len(); i++ {
+		v.Name.SetBit(i, p.Bit(i))
 	}
 	v.Prefix = p
 	v.removeDeadChunks()
@@ -93,13 +95,16 @@ func (v *Vault) removeDeadChunks() {
 
 type oldestFirst []*Vault
 
-func (v oldestFirst) Len() int      { return len(v) }
-func (v oldestFirst) Swap(i, j int) { v[i], v[j] = v[j], v[i] }
-func (v oldestFirst) Less(i, j int) bool {
-	if v[i].Age == v[j].Age {
-		return resolveAgeTiebreaker(v[i], v[j])
+func (v oldestFirst) Len() int           { return len(v) }
+func (v oldestFirst) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+func (v oldestFirst) Less(i, j int) bool { return isOlder(v[i], v[j]) }
+
+// Returns true if a comes before b in oldestFirst order.
+func isOlder(a, b *Vault) bool {
+	if a.Age == b.Age {
+		return resolveAgeTiebreaker(a, b)
 	}
-	return v[i].Age > v[j].Age
+	return a.Age > b.Age
 }
 
 func resolveAgeTiebreaker(vi, vj *Vault) bool {
@@ -107,12 +112,9 @@ func resolveAgeTiebreaker(vi, vj *Vault) bool {
 	// one XOR closest to it
 	// see https://forum.safedev.org/t/data-chains-deeper-dive/1209
 	// in this case the vault xorname is used as the public key
-	x := big.NewInt(0)
-	x.Xor(vi.Name.bigint, vj.Name.bigint)
-	xi := big.NewInt(0)
-	xi.Xor(vi.Name.bigint, x)
-	xj := big.NewInt(0)
-	xj.Xor(vj.Name.bigint, x)
+	x := vi.Name.Xor(vj.Name)
+	xi := vi.Name.Xor(x)
+	xj := vj.Name.Xor(x)
 	// if xi is larger than xj then i should be lower in the sort order
 	// than j since i is further away.
 	return xi.Cmp(xj) == 1
@@ -138,9 +140,9 @@ func (v *Vault) SpareMb() int64 {
 	return v.TotalMb - int64(len(v.Chunks))
 }
 
-func randomStorageSize() int64 {
+func randomStorageSize(n *Network) int64 {
 	// most vaults have smaller storage size
-	n := len(startingStorageSizesMb)
-	i := prng.Intn(n)
-	return startingStorageSizesMb[i]
+	sizes := len(n.Params.StartingStorageSizesMb)
+	i := n.rng.storage.Intn(sizes)
+	return n.Params.StartingStorageSizesMb[i]
 }
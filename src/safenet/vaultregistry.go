@@ -0,0 +1,54 @@
+package safenet
+
+import (
+	"math/rand"
+)
+
+// How GetRandomVault chooses a vault.
+type VaultSampling int
+
+const (
+	// every live vault is equally likely to be chosen
+	UniformVaultSampling VaultSampling = iota
+	// a random section is chosen by name and then a random vault in that
+	// section, so vaults in sections with short prefixes or few vaults are
+	// more likely to be chosen. This was the original behaviour and is kept
+	// so earlier results can be reproduced.
+	SectionWeightedVaultSampling
+)
+
+// An index of every live vault supporting uniform sampling and removal in
+// constant time. Each vault stores its own position in the index.
+type vaultRegistry struct {
+	vaults []*Vault
+}
+
+func (r *vaultRegistry) add(v *Vault) {
+	v.registryIndex = len(r.vaults)
+	r.vaults = append(r.vaults, v)
+}
+
+func (r *vaultRegistry) remove(v *Vault) {
+	i := v.registryIndex
+	if i < 0 || i >= len(r.vaults) || r.vaults[i] != v {
+		return
+	}
+	// move the last vault into the gap
+	last := r.vaults[len(r.vaults)-1]
+	r.vaults[i] = last
+	last.registryIndex = i
+	r.vaults[len(r.vaults)-1] = nil
+	r.vaults = r.vaults[:len(r.vaults)-1]
+	v.registryIndex = -1
+}
+
+func (r *vaultRegistry) random(prng *rand.Rand) *Vault {
+	if len(r.vaults) == 0 {
+		return nil
+	}
+	return r.vaults[prng.Intn(len(r.vaults))]
+}
+
+func (r *vaultRegistry) len() int {
+	return len(r.vaults)
+}
@@ -0,0 +1,48 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestVaultRegistry(t *testing.T) {
+	n := NewNetworkFromSeed(1, DefaultParams())
+	r := vaultRegistry{}
+	if r.random(n.rng.churn) != nil {
+		t.Error("Empty registry returned a vault")
+	}
+	vaults := []*Vault{}
+	for i := 0; i < 10; i++ {
+		v := NewVault(n)
+		r.add(v)
+		vaults = append(vaults, v)
+	}
+	// remove from the middle, the end and the start, then twice
+	for _, i := range []int{4, 9, 0, 4} {
+		r.remove(vaults[i])
+	}
+	if r.len() != 7 {
+		t.Fatalf("Expected 7 vaults, got %d", r.len())
+	}
+	for i, v := range r.vaults {
+		if v.registryIndex != i {
+			t.Errorf("Vault at %d has index %d", i, v.registryIndex)
+		}
+	}
+	for _, i := range []int{0, 4, 9} {
+		if vaults[i].registryIndex != -1 {
+			t.Error("Removed vault keeps its index")
+		}
+	}
+	// every remaining vault is picked and no removed vault is
+	picked := map[*Vault]bool{}
+	for i := 0; i < 1000; i++ {
+		v := r.random(n.rng.churn)
+		if v.registryIndex < 0 {
+			t.Fatal("Picked a removed vault")
+		}
+		picked[v] = true
+	}
+	if len(picked) != 7 {
+		t.Errorf("Picked %d of 7 vaults", len(picked))
+	}
+}
@@ -1,69 +1,131 @@
 package safenet
 
 import (
-	"fmt"
-	"math/big"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+	"math/rand"
 )
 
-type XorName struct {
-	bigint *big.Int
-	bits   []bool
+const xornameBits = 256
+const xornameBytes = xornameBits / 8
+
+// A 256 bit name stored as a fixed width value so names can be copied,
+// compared and used as map keys without allocating.
+// Bit 0 is the most significant bit of the first byte, which is the first
+// bit of any prefix.
+type XorName [xornameBytes]byte
+
+// Creates a name from the network naming prng
+func NewXorName(n *Network) XorName {
+	return newXorName(n.rng.names)
 }
 
-const xornameBits = 256
+func newXorName(prng *rand.Rand) XorName {
+	var x XorName
+	for i := 0; i < xornameBytes; i = i + 8 {
+		binary.BigEndian.PutUint64(x[i:], prng.Uint64())
+	}
+	return x
+}
 
-func NewXorName() XorName {
-	// create a name from prng
-	nameBits := make([]bool, xornameBits)
-	binaryStr := ""
+func (x XorName) BinaryString() string {
+	s := make([]byte, xornameBits)
 	for i := 0; i < xornameBits; i++ {
-		bit := prng.Intn(2)
-		if bit == 0 {
-			nameBits[i] = false
-			binaryStr = binaryStr + "0"
-		} else if bit == 1 {
-			nameBits[i] = true
-			binaryStr = binaryStr + "1"
+		if x.GetBit(i) {
+			s[i] = '1'
 		} else {
-			fmt.Println("Warning: NewXorName generated a number not 0 or 1")
+			s[i] = '0'
 		}
 	}
-	nameBigint := big.NewInt(0)
-	nameBigint.SetString(binaryStr, 2)
-	x := XorName{
-		bigint: nameBigint,
-		bits:   nameBits,
-	}
-	return x
+	return string(s)
 }
 
-func (x XorName) BinaryString() string {
-	s := x.bigint.Text(2)
-	for len(s) < xornameBits {
-		s = "0" + s
+func (x XorName) Hex() string {
+	return hex.EncodeToString(x[:])
+}
+
+func (x XorName) Xor(y XorName) XorName {
+	var z XorName
+	for i := range x {
+		z[i] = x[i] ^ y[i]
 	}
-	return s
+	return z
+}
+
+// Returns -1 if x < y, 0 if x == y and +1 if x > y
+func (x XorName) Cmp(y XorName) int {
+	return bytes.Compare(x[:], y[:])
 }
 
 func (x XorName) IsLessThan(y XorName) bool {
-	return x.bigint.Cmp(y.bigint) == -1
+	return x.Cmp(y) == -1
+}
+
+// Returns true if x is closer to target than y is, by XOR distance
+func (x XorName) IsCloserTo(target, y XorName) bool {
+	return x.Xor(target).IsLessThan(y.Xor(target))
 }
 
 func (x *XorName) SetBit(i int, b bool) {
-	x.bits[i] = b
-	v := uint(0)
+	mask := byte(0x80) >> uint(i%8)
 	if b {
-		v = 1
+		x[i/8] = x[i/8] | mask
+	} else {
+		x[i/8] = x[i/8] &^ mask
 	}
-	x.bigint.SetBit(x.bigint, i, v)
 }
+
 func (x *XorName) GetBit(i int) bool {
-	return x.bits[i]
+	mask := byte(0x80) >> uint(i%8)
+	return x[i/8]&mask != 0
+}
+
+// Returns the number of zero bits at the least significant end of the name,
+// so x % 2^k == 0 for every k up to this value.
+func (x XorName) TrailingZeros() int {
+	zeros := 0
+	for i := xornameBytes - 1; i >= 0; i-- {
+		if x[i] != 0 {
+			return zeros + bits.TrailingZeros8(x[i])
+		}
+		zeros = zeros + 8
+	}
+	return zeros
+}
+
+// calculates r = x % d and returns r == 0
+func (x XorName) ModIsZero(d int64) bool {
+	var r uint64
+	for i := 0; i < xornameBytes; i = i + 8 {
+		w := binary.BigEndian.Uint64(x[i:])
+		_, r = bits.Div64(r, w, uint64(d))
+	}
+	return r == 0
+}
+
+// Returns the number of zero bits at the most significant end of the name.
+func (x XorName) LeadingZeros() int {
+	zeros := 0
+	for i := 0; i < xornameBytes; i++ {
+		if x[i] != 0 {
+			return zeros + bits.LeadingZeros8(x[i])
+		}
+		zeros = zeros + 8
+	}
+	return zeros
 }
 
-func bigIntModInt64IsZero(bi *big.Int, i int64) bool {
-	b64 := big.NewInt(i)
-	result := big.NewInt(1)
-	result.Mod(bi, b64)
-	return result.Cmp(big.NewInt(0)) == 0
+// Returns a copy of the name with every bit from length onwards set to zero.
+func (x XorName) truncate(length int) XorName {
+	if length >= xornameBits {
+		return x
+	}
+	i := length / 8
+	x[i] = x[i] & ^(byte(0xFF) >> uint(length%8))
+	for j := i + 1; j < xornameBytes; j++ {
+		x[j] = 0
+	}
+	return x
 }
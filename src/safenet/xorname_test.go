@@ -0,0 +1,66 @@
+package safenet
+
+import (
+	"testing"
+)
+
+func TestXorNameBits(t *testing.T) {
+	// 0000 0100 0000 0010
+	x := XorName{4, 2}
+	if x.GetBit(4) || !x.GetBit(5) || x.GetBit(6) {
+		t.Error("GetBit in first byte")
+	}
+	if !x.GetBit(14) || x.GetBit(15) {
+		t.Error("GetBit in second byte")
+	}
+	x.SetBit(0, true)
+	if x[0] != 0x84 {
+		t.Error("SetBit true")
+	}
+	x.SetBit(5, false)
+	if x[0] != 0x80 {
+		t.Error("SetBit false")
+	}
+}
+
+func TestXorNameCmp(t *testing.T) {
+	x := XorName{1}
+	y := XorName{0, 0xFF}
+	if !y.IsLessThan(x) || x.IsLessThan(y) {
+		t.Error("IsLessThan compares most significant byte first")
+	}
+	if x.Cmp(x) != 0 {
+		t.Error("Cmp equal")
+	}
+	// x is 1000... and y is 0111..., target 1100... is closer to x
+	target := XorName{0xC0}
+	closer := XorName{0x80}
+	further := XorName{0x7F}
+	if !closer.IsCloserTo(target, further) {
+		t.Error("IsCloserTo")
+	}
+}
+
+func TestXorNameTrailingZeros(t *testing.T) {
+	x := XorName{}
+	if x.TrailingZeros() != xornameBits {
+		t.Error("TrailingZeros for zero name")
+	}
+	x[xornameBytes-2] = 0x10
+	if x.TrailingZeros() != 12 {
+		t.Error("TrailingZeros across bytes")
+	}
+}
+
+func TestXorNameModIsZero(t *testing.T) {
+	x := XorName{}
+	x[xornameBytes-1] = 12
+	if !x.ModIsZero(3) || !x.ModIsZero(4) || x.ModIsZero(5) {
+		t.Error("ModIsZero for small name")
+	}
+	// 2^255 is divisible by any power of two up to 2^63
+	y := XorName{0x80}
+	if !y.ModIsZero(1<<62) || y.ModIsZero(3) {
+		t.Error("ModIsZero for large name")
+	}
+}
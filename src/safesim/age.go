@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"safenet"
+)
+
+func runAgeDistribution(args []string) error {
+	c := defaultChurnConfig()
+	err := parseConfig("age-distribution", args, "config_section_age_distribution.json", &c)
+	if err != nil {
+		return err
+	}
+	out, err := c.start(c)
+	if err != nil {
+		return err
+	}
+	defer c.finish()
+	// simulate each seed concurrently
+	results := safenet.RunSeeds(c.Seed, c.Seeds, c.progress, func(seed int64, progress func(float64)) *safenet.SeedResult {
+		return simulateAgeDistribution(c, seed, progress)
+	})
+	// report
+	fmt.Fprintln(out, c.Seeds, "seeds")
+	fmt.Fprintln(out)
+	// age distribution for all vaults
+	ages := safenet.SummariseHistograms(safenet.HistogramsNamed(results, "age"))
+	safenet.PrintHistogramStats(out, "age", "vaults", ages)
+	fmt.Fprintln(out)
+	// section distribution of adults
+	adults := safenet.SummariseHistograms(safenet.HistogramsNamed(results, "adults"))
+	safenet.PrintHistogramStats(out, "adults", "sections", adults)
+	fmt.Fprintln(out)
+	// elders promoted or demoted in each section by each event
+	churn := safenet.SummariseHistograms(safenet.HistogramsNamed(results, "elder churn"))
+	safenet.PrintHistogramStats(out, "elder_changes", "section_events", churn)
+	fmt.Fprintln(out)
+	// network stats
+	safenet.PrintTotalStats(out, results, "total vaults")
+	safenet.PrintTotalStats(out, results, "total sections")
+	safenet.PrintTotalStats(out, results, "elder demotions")
+	safenet.PrintTotalStats(out, results, "mean elder tenure")
+	safenet.PrintAdmissionStats(out, results)
+	safenet.PrintWarningStats(out, results)
+	return nil
+}
+
+func simulateAgeDistribution(c ChurnConfig, seed int64, progress func(float64)) *safenet.SeedResult {
+	// create network
+	network := c.newNetwork(seed)
+	result := safenet.NewSeedResult(seed)
+	tenure := safenet.Histogram{}
+	network.AddObserver(safenet.NewElderStats(result.Histogram("elder churn"), tenure))
+	network.SimulateChurn(c.Netsize, c.Netsize*5, progress)
+	// report
+	// age distribution for all vaults
+	ageCount, _ := network.ReportAges()
+	ages := result.Histogram("age")
+	for age, count := range ageCount {
+		ages[age] = count
+	}
+	// section distribution of adults
+	adults := result.Histogram("adults")
+	for _, s := range network.Sections() {
+		adults.Add(s.TotalAdults())
+	}
+	// network stats
+	result.Totals["total vaults"] = float64(network.TotalVaults())
+	result.Totals["total sections"] = float64(network.TotalSections())
+	// tenure in steps of elders which were demoted
+	demotions := 0
+	for _, count := range tenure {
+		demotions = demotions + count
+	}
+	result.Totals["elder demotions"] = float64(demotions)
+	result.Totals["mean elder tenure"] = tenure.Moments().Mean
+	result.AddAdmissionStats(network)
+	result.AddWarnings(network)
+	return result
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"safenet"
+)
+
+// The attacker adds vaults until it owns a section, while one normal vault
+// joins and one departs for every ten attacking vaults.
+// The targeted attack gives every attacking vault the same prefix so they
+// are only relocated to the neighbours of that prefix.
+
+type AttackConfig struct {
+	NetworkConfig
+	Snapshot string `json:"snapshot" usage:"save the pre-attack network to this file, or load it if it exists, suffixed by seed if there are several seeds"`
+}
+
+func runAttack(args []string) error {
+	return runAttackCommand("attack", args, "config_google_attack.json", (*safenet.Network).GoogleAttack)
+}
+
+func runTargetedAttack(args []string) error {
+	return runAttackCommand("targeted-attack", args, "config_google_attack_targeted.json", (*safenet.Network).TargetedGoogleAttack)
+}
+
+func runAttackCommand(name string, args []string, configFilename string, attack func(*safenet.Network, func(int)) int) error {
+	c := AttackConfig{
+		NetworkConfig: defaultNetworkConfig(),
+	}
+	err := parseConfig(name, args, configFilename, &c)
+	if err != nil {
+		return err
+	}
+	out, err := c.start(c)
+	if err != nil {
+		return err
+	}
+	defer c.finish()
+	// simulate each seed concurrently
+	results := safenet.RunSeeds(c.Seed, c.Seeds, c.progress, func(seed int64, progress func(float64)) *safenet.SeedResult {
+		snapshot := c.perSeed(c.Snapshot, seed)
+		network := loadOrBuildNetwork(c.NetworkConfig, snapshot, seed, progress)
+		result := safenet.NewSeedResult(seed)
+		result.Totals["vaults before attack"] = float64(network.TotalVaults())
+		// atack the network until the attacker owns a section
+		attackVaultCount := attack(network, nil)
+		result.Totals["attacking vaults added to own a section"] = float64(attackVaultCount)
+		result.Totals["vaults after attack"] = float64(network.TotalVaults())
+		result.Totals["sections after attack"] = float64(network.TotalSections())
+		pctOwned := float64(attackVaultCount) / float64(network.TotalVaults()) * 100
+		result.Totals["percent of total network owned by attacker"] = pctOwned
+		result.AddAdmissionStats(network)
+		result.AddWarnings(network)
+		return result
+	})
+	// report
+	fmt.Fprintln(out, c.Seeds, "seeds")
+	fmt.Fprintln(out)
+	safenet.PrintTotalStats(out, results, "vaults before attack")
+	safenet.PrintTotalStats(out, results, "attacking vaults added to own a section")
+	safenet.PrintTotalStats(out, results, "vaults after attack")
+	safenet.PrintTotalStats(out, results, "sections after attack")
+	safenet.PrintTotalStats(out, results, "percent of total network owned by attacker")
+	safenet.PrintAdmissionStats(out, results)
+	safenet.PrintWarningStats(out, results)
+	return nil
+}
+
+func loadOrBuildNetwork(c NetworkConfig, snapshot string, seed int64, progress func(float64)) *safenet.Network {
+	if snapshot != "" {
+		network, err := safenet.LoadSnapshot(snapshot)
+		if err == nil && network.Label != c.buildLabel(seed) {
+			err = fmt.Errorf("snapshot was built with a different config, rebuilding")
+		}
+		if err == nil {
+			fmt.Fprintln(c.progress, "Loaded initial network from", snapshot)
+			c.configure(network)
+			return network
+		}
+		fmt.Fprintln(c.progress, "Could not load snapshot", snapshot)
+		fmt.Fprintln(c.progress, err)
+	}
+	// Create initial network
+	network := c.newNetwork(seed)
+	network.SimulateChurn(c.Netsize, c.Netsize*5, progress)
+	network.Label = c.buildLabel(seed)
+	if snapshot != "" {
+		err := network.SaveSnapshot(snapshot)
+		if err != nil {
+			fmt.Fprintln(c.progress, "Could not save snapshot", snapshot)
+			fmt.Fprintln(c.progress, err)
+		} else {
+			fmt.Fprintln(c.progress, "Saved initial network to", snapshot)
+		}
+	}
+	return network
+}
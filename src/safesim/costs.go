@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"safenet"
+)
+
+// Estimates the messages sent for every join, departure, relocation, split
+// and merge while the network grows and churns, using the cost model from
+// the config.
+
+type MessageCostConfig struct {
+	ChurnConfig
+	Interval int `json:"interval" usage:"steps in each row of the messages over time table, 0 for netsize"`
+	safenet.CostModel
+}
+
+func runMessageCosts(args []string) error {
+	c := MessageCostConfig{
+		ChurnConfig: defaultChurnConfig(),
+		CostModel:   safenet.DefaultCostModel(),
+	}
+	err := parseConfig("message-costs", args, "config_message_costs.json", &c)
+	if err != nil {
+		return err
+	}
+	if c.Interval <= 0 {
+		c.Interval = c.Netsize
+	}
+	out, err := c.start(c)
+	if err != nil {
+		return err
+	}
+	defer c.finish()
+	// simulate each seed concurrently
+	results := safenet.RunSeeds(c.Seed, c.Seeds, c.progress, func(seed int64, progress func(float64)) *safenet.SeedResult {
+		return simulateMessageCosts(c, seed, progress)
+	})
+	// report
+	fmt.Fprintln(out, c.Seeds, "seeds")
+	fmt.Fprintln(out)
+	// messages and bytes sent in each interval of steps
+	messages := safenet.SummariseHistograms(safenet.HistogramsNamed(results, "messages"))
+	safenet.PrintHistogramStats(out, "step", "messages", messages)
+	fmt.Fprintln(out)
+	bytes := safenet.SummariseHistograms(safenet.HistogramsNamed(results, "bytes"))
+	safenet.PrintHistogramStats(out, "step", "bytes", bytes)
+	fmt.Fprintln(out)
+	safenet.PrintTotalStats(out, results, "total vaults")
+	safenet.PrintTotalStats(out, results, "total sections")
+	safenet.PrintMessageStats(out, results)
+	safenet.PrintAdmissionStats(out, results)
+	safenet.PrintWarningStats(out, results)
+	return nil
+}
+
+func simulateMessageCosts(c MessageCostConfig, seed int64, progress func(float64)) *safenet.SeedResult {
+	// create network
+	network := c.newNetwork(seed)
+	network.CostModel = c.CostModel
+	result := safenet.NewSeedResult(seed)
+	stats := safenet.NewMessageStats(c.Interval, result.Histogram("messages"), result.Histogram("bytes"))
+	network.AddObserver(stats)
+	network.SimulateChurn(c.Netsize, c.Netsize*5, progress)
+	// report
+	result.Totals["total vaults"] = float64(network.TotalVaults())
+	result.Totals["total sections"] = float64(network.TotalSections())
+	result.AddMessageStats(stats)
+	result.AddAdmissionStats(network)
+	result.AddWarnings(network)
+	return result
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"safenet"
+)
+
+// When a vault is relocated, it goes to the best neighbourhood.
+// Finding the best neighbourhood is a recursive process, so the vault may end
+// up several neighbourhoods away.
+// This looks at how far vaults are relocated, as in, 1 neighbourhood away
+// or 2 neighbourhoods away etc.
+
+type RelocationHopsConfig struct {
+	ChurnConfig
+	Trace string `json:"trace" usage:"write membership events as JSON Lines to this file, suffixed by seed if there are several seeds"`
+}
+
+func runRelocationHops(args []string) error {
+	c := RelocationHopsConfig{
+		ChurnConfig: defaultChurnConfig(),
+	}
+	err := parseConfig("relocation-hops", args, "config_neighbour_relocation_hops.json", &c)
+	if err != nil {
+		return err
+	}
+	out, err := c.start(c)
+	if err != nil {
+		return err
+	}
+	defer c.finish()
+	// simulate each seed concurrently
+	results := safenet.RunSeeds(c.Seed, c.Seeds, c.progress, func(seed int64, progress func(float64)) *safenet.SeedResult {
+		trace := c.perSeed(c.Trace, seed)
+		return simulateRelocationHops(c, seed, trace, progress)
+	})
+	// report
+	fmt.Fprintln(out, c.Seeds, "seeds")
+	fmt.Fprintln(out)
+	hops := safenet.SummariseHistograms(safenet.HistogramsNamed(results, "hops"))
+	safenet.PrintHistogramStats(out, "hops", "occurances", hops)
+	fmt.Fprintln(out)
+	cascades := safenet.SummariseHistograms(safenet.HistogramsNamed(results, "cascade"))
+	safenet.PrintHistogramStats(out, "relocations_per_event", "events", cascades)
+	fmt.Fprintln(out)
+	safenet.PrintTotalStats(out, results, "total vaults")
+	safenet.PrintTotalStats(out, results, "total relocations")
+	safenet.PrintAdmissionStats(out, results)
+	safenet.PrintWarningStats(out, results)
+	return nil
+}
+
+func simulateRelocationHops(c RelocationHopsConfig, seed int64, trace string, progress func(float64)) *safenet.SeedResult {
+	// create network
+	network := c.newNetwork(seed)
+	result := safenet.NewSeedResult(seed)
+	network.AddObserver(newCascadeObserver(result.Histogram("cascade")))
+	if trace != "" {
+		f, err := os.Create(trace)
+		if err != nil {
+			fmt.Fprintln(c.progress, "Could not create trace file", trace)
+			fmt.Fprintln(c.progress, err)
+		} else {
+			w := bufio.NewWriter(f)
+			defer f.Close()
+			defer w.Flush()
+			network.SetEventTrace(w)
+		}
+	}
+	// Create initial network
+	network.SimulateChurn(c.Netsize, c.Netsize*12/10, progress)
+	// report
+	hops := result.Histogram("hops")
+	for _, h := range network.NeighbourhoodHops {
+		hops.Add(h)
+	}
+	result.Totals["total vaults"] = float64(network.TotalVaults())
+	result.Totals["total relocations"] = float64(network.TotalRelocations)
+	result.AddAdmissionStats(network)
+	result.AddWarnings(network)
+	return result
+}
+
+// Counts how many relocations each join or departure started by the
+// simulation causes, including relocations caused indirectly by merges and
+// other relocations.
+type cascadeObserver struct {
+	safenet.BaseObserver
+	relocations map[int]int
+	cascades    safenet.Histogram
+}
+
+func newCascadeObserver(h safenet.Histogram) *cascadeObserver {
+	return &cascadeObserver{
+		relocations: map[int]int{},
+		cascades:    h,
+	}
+}
+
+// Events are delivered after the events they cause, so by the time an event
+// arrives the relocations it caused have been counted against its seq.
+func (c *cascadeObserver) complete(e *safenet.MembershipEvent) {
+	count := c.relocations[e.Seq]
+	delete(c.relocations, e.Seq)
+	if e.Type == safenet.RelocationEvent {
+		count = count + 1
+	}
+	if e.Cause == 0 {
+		c.cascades.Add(count)
+	} else if count > 0 {
+		c.relocations[e.Cause] = c.relocations[e.Cause] + count
+	}
+}
+
+func (c *cascadeObserver) OnJoin(e *safenet.MembershipEvent)      { c.complete(e) }
+func (c *cascadeObserver) OnDeparture(e *safenet.MembershipEvent) { c.complete(e) }
+func (c *cascadeObserver) OnSplit(e *safenet.MembershipEvent)     { c.complete(e) }
+func (c *cascadeObserver) OnMerge(e *safenet.MembershipEvent)     { c.complete(e) }
+func (c *cascadeObserver) OnRelocate(e *safenet.MembershipEvent)  { c.complete(e) }
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"safenet"
+)
+
+// safesim runs each of the simulations as a subcommand, eg
+//
+//   safesim size-distribution -seeds 20 -netsize 10000
+//
+// Every subcommand fills its config from its config file layered over
+// config_all.json, then lets flags override the config values, and writes
+// its report to stdout or -output.
+
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{"size-distribution", "number of sections of each size after churn", runSizeDistribution},
+	{"age-distribution", "vault ages and adults per section after churn", runAgeDistribution},
+	{"relocation-hops", "how many neighbourhoods away vaults are relocated", runRelocationHops},
+	{"attack", "attacking vaults needed to own a section", runAttack},
+	{"targeted-attack", "attacking vaults needed to own a section when targeting one prefix", runTargetedAttack},
+	{"message-costs", "estimated messages and bytes sent for membership changes", runMessageCosts},
+	{"routing", "route hops and routing table sizes as the network grows", runRouting},
+	{"safecoin", "safecoin supply and farming rate as clients join each day", runSafecoin},
+	{"sweep", "size distribution and attack for every combination of parameter ranges, as CSV", runSweep},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		err := c.run(os.Args[2:])
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if name != "help" && name != "-h" && name != "-help" {
+		fmt.Fprintln(os.Stderr, "Unknown command", name)
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: safesim <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", c.name, c.description)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run safesim <command> -h for the flags of a command.")
+}
+
+// Parses the flags and config files for the named command into config, then
+// checks the values if the config has a validate method.
+func parseConfig(name string, args []string, defaultFilename string, config interface{}) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	err := safenet.ParseConfig(fs, args, defaultFilename, config)
+	if err != nil {
+		return err
+	}
+	if v, ok := config.(interface{ validate() error }); ok {
+		return v.validate()
+	}
+	return nil
+}
+
+// The config shared by every command. Config structs must be exported for
+// their fields to be set by flags.
+type BaseConfig struct {
+	Seed   int64  `json:"seed" usage:"seed for the prng"`
+	Seeds  int    `json:"seeds" usage:"number of consecutive seeds to simulate"`
+	Output string `json:"output" usage:"write the report to this file instead of stdout"`
+	Quiet  bool   `json:"quiet" usage:"do not show progress"`
+	file   *os.File
+	// set by start
+	progress io.Writer
+}
+
+func defaultBaseConfig() BaseConfig {
+	return BaseConfig{
+		Seeds: 1,
+	}
+}
+
+// Sends progress to stderr unless quiet, shows the config and opens the
+// output. finish must be called once the report is written.
+func (c *BaseConfig) start(config interface{}) (io.Writer, error) {
+	c.progress = os.Stderr
+	if c.Quiet {
+		c.progress = ioutil.Discard
+	}
+	shown, err := json.Marshal(config)
+	if err == nil {
+		fmt.Fprintln(c.progress, "Configured to use", string(shown))
+	}
+	if c.Output == "" {
+		return os.Stdout, nil
+	}
+	c.file, err = os.Create(c.Output)
+	if err != nil {
+		return nil, err
+	}
+	return c.file, nil
+}
+
+func (c *BaseConfig) finish() error {
+	if c.file == nil {
+		return nil
+	}
+	return c.file.Close()
+}
+
+// Adds a seed suffix to filenames written per seed when there are several
+// seeds.
+func (c *BaseConfig) perSeed(filename string, seed int64) string {
+	if filename == "" || c.Seeds <= 1 {
+		return filename
+	}
+	return fmt.Sprintf("%s.%d", filename, seed)
+}
+
+// The config for commands which simulate networks of one size with one set of
+// protocol params.
+type NetworkConfig struct {
+	BaseConfig
+	Netsize            int    `json:"netsize" usage:"number of vaults in the network"`
+	Debug              bool   `json:"debug" usage:"check network invariants after every event, which is slow"`
+	ContentEventHashes bool   `json:"content_event_hashes" usage:"hash the content of events instead of drawing random event hashes"`
+	RelocationTrigger  string `json:"relocation_trigger" usage:"which vault is relocated after each event: hash-age, xor-tiebreak, churn-counter or off"`
+	Relocation         string `json:"relocation" usage:"relocation target strategy: smallest-neighbour, closest-to-hash, random-neighbour or hash-anywhere"`
+	// rejected vaults wait in the join queue
+	Admission      string `json:"admission" usage:"join admission policy: always, ageing-sim or rate-limit"`
+	AdmissionJoins int    `json:"admission_joins" usage:"joins each section admits per window for the rate-limit policy"`
+	AdmissionSteps int    `json:"admission_steps" usage:"length of the window in steps for the rate-limit policy"`
+	safenet.Params
+}
+
+func defaultNetworkConfig() NetworkConfig {
+	return NetworkConfig{
+		BaseConfig:        defaultBaseConfig(),
+		Netsize:           100000,
+		RelocationTrigger: safenet.HashAgeTriggerName,
+		Relocation:        safenet.SmallestNeighbourRelocationName,
+		Admission:         safenet.AlwaysAdmitName,
+		AdmissionJoins:    1,
+		AdmissionSteps:    10,
+		Params:            safenet.DefaultParams(),
+	}
+}
+
+func (c *NetworkConfig) validate() error {
+	err := c.Params.Validate()
+	if err != nil {
+		return err
+	}
+	_, err = safenet.NewRelocationTrigger(c.RelocationTrigger)
+	if err != nil {
+		return err
+	}
+	_, err = safenet.NewRelocationStrategy(c.Relocation)
+	if err != nil {
+		return err
+	}
+	_, err = c.admissionPolicy()
+	return err
+}
+
+// Each network needs its own policy since policies may keep state.
+func (c *NetworkConfig) admissionPolicy() (safenet.AdmissionPolicy, error) {
+	return safenet.NewAdmissionPolicy(c.Admission, c.AdmissionJoins, c.AdmissionSteps)
+}
+
+// Sets the options which are not part of the network state, for new and
+// loaded networks.
+func (c *NetworkConfig) configure(network *safenet.Network) {
+	network.Debug = c.Debug
+	network.EventHashing = safenet.RandomEventHashing
+	if c.ContentEventHashes {
+		network.EventHashing = safenet.ContentEventHashing
+	}
+	// already checked by validate
+	network.RelocationTrigger, _ = safenet.NewRelocationTrigger(c.RelocationTrigger)
+	network.Relocation, _ = safenet.NewRelocationStrategy(c.Relocation)
+	network.Admission, _ = c.admissionPolicy()
+}
+
+// Describes every setting that changes how a network is built from seed, so
+// a saved network can be matched to the config. Output options and Debug do
+// not change the network.
+func (c NetworkConfig) buildLabel(seed int64) string {
+	c.BaseConfig = BaseConfig{Seed: seed}
+	c.Debug = false
+	label, _ := json.Marshal(c)
+	return string(label)
+}
+
+func (c *NetworkConfig) newNetwork(seed int64) *safenet.Network {
+	network := safenet.NewNetworkFromSeed(seed, c.Params)
+	c.configure(network)
+	return network
+}
+
+// The config for commands which churn a network
+type ChurnConfig struct {
+	NetworkConfig
+	SectionWeighted bool `json:"section_weighted" usage:"choose departing vaults by section instead of uniformly"`
+}
+
+func defaultChurnConfig() ChurnConfig {
+	return ChurnConfig{
+		NetworkConfig: defaultNetworkConfig(),
+	}
+}
+
+func (c *ChurnConfig) newNetwork(seed int64) *safenet.Network {
+	network := c.NetworkConfig.newNetwork(seed)
+	if c.SectionWeighted {
+		network.VaultSampling = safenet.SectionWeightedVaultSampling
+	}
+	return network
+}
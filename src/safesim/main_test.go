@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"safenet"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigFlags(t *testing.T) {
+	c := AttackConfig{
+		NetworkConfig: defaultNetworkConfig(),
+	}
+	args := []string{"-netsize", "500", "-group-size", "10", "-content-event-hashes", "-snapshot", "a.gob"}
+	err := parseConfig("attack", args, "missing.json", &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Netsize != 500 || c.GroupSize != 10 || !c.ContentEventHashes || c.Snapshot != "a.gob" {
+		t.Errorf("Parsed %+v", c)
+	}
+	// other fields keep their defaults
+	if c.SplitBuffer != 3 || c.Admission != safenet.AlwaysAdmitName || c.Seeds != 1 {
+		t.Errorf("Defaults changed to %+v", c)
+	}
+	c = AttackConfig{
+		NetworkConfig: defaultNetworkConfig(),
+	}
+	if parseConfig("attack", []string{"-admission", "none"}, "missing.json", &c) == nil {
+		t.Error("Expected an error for an unknown admission policy")
+	}
+}
+
+func TestConfigureSetsEventHashing(t *testing.T) {
+	c := defaultNetworkConfig()
+	n := safenet.NewNetwork(c.Params)
+	c.ContentEventHashes = true
+	c.configure(n)
+	if n.EventHashing != safenet.ContentEventHashing {
+		t.Error("Content hashing was not set")
+	}
+	c.ContentEventHashes = false
+	c.configure(n)
+	if n.EventHashing != safenet.RandomEventHashing {
+		t.Error("Content hashing was kept")
+	}
+}
+
+func TestSnapshotRebuiltForOtherConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "safesim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	snapshot := filepath.Join(dir, "network.gob")
+	progress := bytes.Buffer{}
+	c := defaultNetworkConfig()
+	c.Netsize = 50
+	c.progress = &progress
+	built := loadOrBuildNetwork(c, snapshot, 1, nil)
+	// the same config loads the snapshot, debug does not matter
+	c.Debug = true
+	progress.Reset()
+	loaded := loadOrBuildNetwork(c, snapshot, 1, nil)
+	if !strings.Contains(progress.String(), "Loaded") || loaded.TotalVaults() != built.TotalVaults() || !loaded.Debug {
+		t.Errorf("Snapshot was not loaded: %s", progress.String())
+	}
+	// another seed, admission or event hashing rebuilds it
+	changes := map[string]func(c *NetworkConfig) int64{
+		"seed":      func(c *NetworkConfig) int64 { return 2 },
+		"admission": func(c *NetworkConfig) int64 { c.Admission = safenet.RateLimitAdmissionName; return 1 },
+		"hashing":   func(c *NetworkConfig) int64 { c.ContentEventHashes = true; return 1 },
+	}
+	for name, change := range changes {
+		other := c
+		seed := change(&other)
+		progress.Reset()
+		loadOrBuildNetwork(other, snapshot, seed, nil)
+		if !strings.Contains(progress.String(), "rebuilding") {
+			t.Errorf("Snapshot was not rebuilt for a different %s", name)
+		}
+		// restore the original snapshot
+		loadOrBuildNetwork(c, snapshot, 1, nil)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"safenet"
+)
+
+// Grows the network to netsize and, at evenly spaced sizes along the way,
+// routes messages between random names to see how many hops each GET and PUT
+// takes, and how many sections each routing table holds.
+
+type RoutingConfig struct {
+	NetworkConfig
+	Checkpoints int `json:"checkpoints" usage:"number of network sizes to measure routing at, evenly spaced up to netsize"`
+	Routes      int `json:"routes" usage:"routes between random names sampled at each network size"`
+}
+
+func (c *RoutingConfig) validate() error {
+	if c.Checkpoints < 1 || c.Checkpoints > c.Netsize {
+		return fmt.Errorf("checkpoints must be from 1 to netsize, got %d", c.Checkpoints)
+	}
+	return c.NetworkConfig.validate()
+}
+
+// The network sizes routing is measured at
+func (c *RoutingConfig) sizes() []int {
+	sizes := []int{}
+	for i := 1; i <= c.Checkpoints; i++ {
+		sizes = append(sizes, c.Netsize*i/c.Checkpoints)
+	}
+	return sizes
+}
+
+func runRouting(args []string) error {
+	c := RoutingConfig{
+		NetworkConfig: defaultNetworkConfig(),
+		Checkpoints:   10,
+		Routes:        1000,
+	}
+	err := parseConfig("routing", args, "config_routing.json", &c)
+	if err != nil {
+		return err
+	}
+	out, err := c.start(c)
+	if err != nil {
+		return err
+	}
+	defer c.finish()
+	// simulate each seed concurrently
+	results := safenet.RunSeeds(c.Seed, c.Seeds, c.progress, func(seed int64, progress func(float64)) *safenet.SeedResult {
+		return simulateRouting(c, seed, progress)
+	})
+	// report
+	fmt.Fprintln(out, c.Seeds, "seeds")
+	fmt.Fprintln(out)
+	for _, size := range c.sizes() {
+		fmt.Fprintln(out, size, "vaults")
+		hops := safenet.SummariseHistograms(safenet.HistogramsNamed(results, fmt.Sprintf("hops at %d", size)))
+		safenet.PrintHistogramStats(out, "hops", "routes", hops)
+		tables := safenet.SummariseHistograms(safenet.HistogramsNamed(results, fmt.Sprintf("table size at %d", size)))
+		safenet.PrintHistogramStats(out, "table_size", "sections", tables)
+		safenet.PrintTotalStats(out, results, fmt.Sprintf("sections at %d", size))
+		safenet.PrintTotalStats(out, results, fmt.Sprintf("mean hops at %d", size))
+		safenet.PrintTotalStats(out, results, fmt.Sprintf("mean table size at %d", size))
+		fmt.Fprintln(out)
+	}
+	safenet.PrintTotalStats(out, results, "total vaults")
+	safenet.PrintAdmissionStats(out, results)
+	safenet.PrintWarningStats(out, results)
+	return nil
+}
+
+func simulateRouting(c RoutingConfig, seed int64, progress func(float64)) *safenet.SeedResult {
+	// create network
+	network := c.newNetwork(seed)
+	result := safenet.NewSeedResult(seed)
+	previous := 0
+	for _, size := range c.sizes() {
+		// grow without departures, rejected vaults wait in the join queue
+		network.SimulateChurn(size, size-previous, nil)
+		previous = size
+		progress(float64(size) / float64(c.Netsize))
+		// route between random names
+		hops := result.Histogram(fmt.Sprintf("hops at %d", size))
+		network.SampleRoutes(c.Routes, hops)
+		result.Totals[fmt.Sprintf("mean hops at %d", size)] = hops.Moments().Mean
+		// routing table sizes
+		tables := result.Histogram(fmt.Sprintf("table size at %d", size))
+		for _, t := range network.RoutingTables() {
+			tables.Add(t.Len())
+		}
+		result.Totals[fmt.Sprintf("mean table size at %d", size)] = tables.Moments().Mean
+		result.Totals[fmt.Sprintf("sections at %d", size)] = float64(network.TotalSections())
+	}
+	result.Totals["total vaults"] = float64(network.TotalVaults())
+	result.AddAdmissionStats(network)
+	result.AddWarnings(network)
+	return result
+}
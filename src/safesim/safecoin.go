@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"safenet"
 	"time"
 )
@@ -9,15 +11,59 @@ import (
 const numIcoCoins = 452552412
 const growthRate = 1.003 // 0.3% growth every day
 
-func main() {
+type SafecoinConfig struct {
+	BaseConfig
+	Days              int    `json:"days" usage:"number of days to simulate"`
+	Debug             bool   `json:"debug" usage:"check network invariants after every event, which is slow"`
+	RelocationTrigger string `json:"relocation_trigger" usage:"which vault is relocated after each event: hash-age, xor-tiebreak, churn-counter or off"`
+	safenet.Params
+}
+
+func (c *SafecoinConfig) validate() error {
+	// the daily output is a single run, it is not summarised across seeds
+	if c.Seeds > 1 {
+		return fmt.Errorf("safecoin simulates one seed, got %d seeds", c.Seeds)
+	}
+	err := c.Params.Validate()
+	if err != nil {
+		return err
+	}
+	_, err = safenet.NewRelocationTrigger(c.RelocationTrigger)
+	return err
+}
+
+func runSafecoin(args []string) error {
+	c := SafecoinConfig{
+		BaseConfig:        defaultBaseConfig(),
+		Days:              100000,
+		RelocationTrigger: safenet.HashAgeTriggerName,
+		Params:            safenet.DefaultParams(),
+	}
+	err := parseConfig("safecoin", args, "config_safecoin_simulation.json", &c)
+	if err != nil {
+		return err
+	}
+	out, err := c.start(c)
+	if err != nil {
+		return err
+	}
+	defer c.finish()
+	simulateSafecoin(c, out)
+	return nil
+}
+
+func simulateSafecoin(c SafecoinConfig, out io.Writer) {
 	// create network
-	n := safenet.NewNetwork()
+	n := safenet.NewNetworkFromSeed(c.Seed, c.Params)
+	n.Debug = c.Debug
+	// already checked by validate
+	n.RelocationTrigger, _ = safenet.NewRelocationTrigger(c.RelocationTrigger)
 	// initialize ICO coins
-	fmt.Println("Initializing ICO coins")
-	initIcoCoins(&n)
-	fmt.Println()
+	fmt.Fprintln(c.progress, "Initializing ICO coins")
+	initIcoCoins(n, c.progress)
+	fmt.Fprintln(c.progress)
 	// initialize 1000 MaidSafe vaults
-	maidsafeClient := safenet.NewConsistentClient()
+	maidsafeClient := safenet.NewConsistentClient(n)
 	n.AddClient(maidsafeClient)
 	for n.TotalVaults() < 1000 {
 		vaults := maidsafeClient.NewVaultsToStart()
@@ -25,22 +71,20 @@ func main() {
 			n.AddVault(v)
 		}
 	}
-	// initialize report
-	report := "endOfDay,totalSafecoin,mbPerSafecoin,farmDivisor,totalSections,totalVaults,totalClients,secondsToSimulate\n"
+	// report header
+	fmt.Fprint(out, "endOfDay,totalSafecoin,mbPerSafecoin,farmDivisor,totalSections,totalVaults,totalClients,secondsToSimulate\n")
 	// calculate average mb per safecoin
 	mbPerSafecoin := 1.0 / n.AvgSafecoinPerMb()
 	farmDivisor := n.AvgFarmDivisor()
-	report = report + fmt.Sprintf("%d,%d,%f,%f,%d,%d,%d,%f\n", 0, n.TotalSafecoins(), mbPerSafecoin, farmDivisor, n.TotalSections(), n.TotalVaults(), n.TotalClients(), 0.0)
 	// report current state
-	fmt.Print(report)
+	fmt.Fprintf(out, "%d,%d,%f,%f,%d,%d,%d,%f\n", 0, n.TotalSafecoins(), mbPerSafecoin, farmDivisor, n.TotalSections(), n.TotalVaults(), n.TotalClients(), 0.0)
 	// simulate the network activity by creating clients
-	days := 100000
-	for day := 1; day < days; day++ {
+	for day := 1; day < c.Days; day++ {
 		// create new clients
 		startTimer := time.Now()
 		newClientsForToday := int(float64(n.TotalClients()) * (growthRate - 1))
 		for i := 0; i < newClientsForToday; i++ {
-			c := safenet.NewRandomClient()
+			c := safenet.NewRandomClient(n)
 			n.AddClient(c)
 		}
 		// do each client activity
@@ -58,7 +102,7 @@ func main() {
 				n.RemoveVault(v)
 			}
 			// buy put balance
-			c.ConvertCoinsToPutBalance(day, c, &n)
+			c.ConvertCoinsToPutBalance(day, c, n)
 			// do puts
 			totalPuts := c.MbPutForDay(day)
 			for p := 0.0; p < totalPuts; p++ {
@@ -76,14 +120,14 @@ func main() {
 		// get timing stats
 		timeToSimulate := time.Now().Sub(startTimer).Seconds()
 		// add day to report
-		line := fmt.Sprintf("%d,%d,%f,%f,%d,%d,%d,%f\n", day, n.TotalSafecoins(), mbPerSafecoin, farmDivisor, n.TotalSections(), n.TotalVaults(), n.TotalClients(), timeToSimulate)
-		fmt.Print(line)
-		report = report + line
+		fmt.Fprintf(out, "%d,%d,%f,%f,%d,%d,%d,%f\n", day, n.TotalSafecoins(), mbPerSafecoin, farmDivisor, n.TotalSections(), n.TotalVaults(), n.TotalClients(), timeToSimulate)
+	}
+	// warnings go to stderr so the report stays valid CSV
+	for message, count := range n.Warnings() {
+		fmt.Fprintln(os.Stderr, count, "warnings:", message)
 	}
-	//fmt.Println(report)
 }
-
-func initIcoCoins(n *safenet.Network) {
+func initIcoCoins(n *safenet.Network, progress io.Writer) {
 	// create holder clients and distribute safecoins based on distribution at
 	// https://omniexplorer.info/spstats.aspx?sp=3
 	// create clients for 0-10 coins
@@ -96,9 +140,9 @@ func initIcoCoins(n *safenet.Network) {
 	distribution = append(distribution, []int{1640, 10000, 100000})
 	var totalDistributed int32
 	for _, d := range distribution {
-		fmt.Println("Distributing", d[1], "-", d[2], "coins to", d[0], "ICO clients")
+		fmt.Fprintln(progress, "Distributing", d[1], "-", d[2], "coins to", d[0], "ICO clients")
 		for i := 0; i < d[0]; i++ {
-			c := safenet.NewHolderClient()
+			c := safenet.NewHolderClient(n)
 			// TODO use random distribution instead of average
 			coins := int32((d[1] + d[2]) / 2)
 			c.AllocateSafecoins(coins)
@@ -159,9 +203,9 @@ func initIcoCoins(n *safenet.Network) {
 		800000,
 		795000,
 	}
-	fmt.Println("Distributing to top 50 coin holders")
+	fmt.Fprintln(progress, "Distributing to top 50 coin holders")
 	for _, coins := range topHolders {
-		c := safenet.NewRandomClient()
+		c := safenet.NewRandomClient(n)
 		c.AllocateSafecoins(coins)
 		n.AddClient(c)
 		totalDistributed = totalDistributed + coins
@@ -170,20 +214,20 @@ func initIcoCoins(n *safenet.Network) {
 	remaining := numIcoCoins - totalDistributed
 	totalRichClients := 450 - len(topHolders)
 	coins := int32(float64(remaining) / float64(totalRichClients))
-	fmt.Println("Distributing", coins, "coins each to", totalRichClients-1, "rich ICO clients")
+	fmt.Fprintln(progress, "Distributing", coins, "coins each to", totalRichClients-1, "rich ICO clients")
 	for i := 0; i < totalRichClients-1; i++ {
-		c := safenet.NewRandomClient()
+		c := safenet.NewRandomClient(n)
 		c.AllocateSafecoins(coins)
 		n.AddClient(c)
 		totalDistributed = totalDistributed + coins
 	}
 	// distribute remaining coins to last rich client
 	coins = numIcoCoins - totalDistributed
-	fmt.Println("Distributing", coins, "remaining coins to final rich ICO client")
-	c := safenet.NewRandomClient()
+	fmt.Fprintln(progress, "Distributing", coins, "remaining coins to final rich ICO client")
+	c := safenet.NewRandomClient(n)
 	c.AllocateSafecoins(coins)
 	n.AddClient(c)
 	totalDistributed = totalDistributed + coins
 	// Log the result of issuing ico coins
-	fmt.Println("Issued", totalDistributed, "of", numIcoCoins, "ICO coins to", n.TotalClients(), "clients")
+	fmt.Fprintln(progress, "Issued", totalDistributed, "of", numIcoCoins, "ICO coins to", n.TotalClients(), "clients")
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"safenet"
+)
+
+func runSizeDistribution(args []string) error {
+	c := defaultChurnConfig()
+	err := parseConfig("size-distribution", args, "config_section_size_distribution.json", &c)
+	if err != nil {
+		return err
+	}
+	out, err := c.start(c)
+	if err != nil {
+		return err
+	}
+	defer c.finish()
+	// simulate each seed concurrently
+	results := safenet.RunSeeds(c.Seed, c.Seeds, c.progress, func(seed int64, progress func(float64)) *safenet.SeedResult {
+		return simulateSizeDistribution(c, seed, progress)
+	})
+	// report
+	sizes := safenet.SummariseHistograms(safenet.HistogramsNamed(results, "size"))
+	fmt.Fprintln(out, c.Seeds, "seeds")
+	fmt.Fprintln(out)
+	safenet.PrintHistogramStats(out, "size", "count", sizes)
+	fmt.Fprintln(out)
+	safenet.PrintTotalStats(out, results, "total vaults")
+	safenet.PrintTotalStats(out, results, "total joins")
+	safenet.PrintTotalStats(out, results, "total departures")
+	safenet.PrintTotalStats(out, results, "total sections")
+	safenet.PrintTotalStats(out, results, "total splits")
+	safenet.PrintTotalStats(out, results, "total merges")
+	safenet.PrintAdmissionStats(out, results)
+	safenet.PrintWarningStats(out, results)
+	return nil
+}
+
+func simulateSizeDistribution(c ChurnConfig, seed int64, progress func(float64)) *safenet.SeedResult {
+	// create network
+	network := c.newNetwork(seed)
+	network.SimulateChurn(c.Netsize, c.Netsize*5, progress)
+	// report
+	result := safenet.NewSeedResult(seed)
+	sizes := result.Histogram("size")
+	for _, s := range network.Sections() {
+		sizes.Add(len(s.Vaults))
+	}
+	result.Totals["total vaults"] = float64(network.TotalVaults())
+	result.Totals["total joins"] = float64(network.TotalJoins)
+	result.Totals["total departures"] = float64(network.TotalDepartures)
+	result.Totals["total sections"] = float64(network.TotalSections())
+	result.Totals["total splits"] = float64(network.TotalSplits)
+	result.Totals["total merges"] = float64(network.TotalMerges)
+	result.AddAdmissionStats(network)
+	result.AddWarnings(network)
+	return result
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"safenet"
+)
+
+// Runs the section size simulation and the google attack for every
+// combination of the parameter ranges in the config file and writes one CSV
+// row per combination.
+
+type SweepConfig struct {
+	BaseConfig
+	safenet.SweepConfig
+}
+
+func runSweep(args []string) error {
+	c := SweepConfig{
+		BaseConfig:  defaultBaseConfig(),
+		SweepConfig: safenet.DefaultSweepConfig(),
+	}
+	err := parseConfig("sweep", args, "config_parameter_sweep.json", &c)
+	if err != nil {
+		return err
+	}
+	out, err := c.start(c)
+	if err != nil {
+		return err
+	}
+	defer c.finish()
+	return safenet.RunSweep(c.SweepConfig, c.Seed, c.Seeds, out, c.progress)
+}